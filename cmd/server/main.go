@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"flag"
 	"fmt"
 	"log"
@@ -11,11 +12,18 @@ import (
 	"syscall"
 	"time"
 
+	"whatsapp-ia-integrator/internal/admin"
+	"whatsapp-ia-integrator/internal/calendar"
 	"whatsapp-ia-integrator/internal/chatvolt"
 	"whatsapp-ia-integrator/internal/config"
 	"whatsapp-ia-integrator/internal/csa"
+	"whatsapp-ia-integrator/internal/dedup"
+	"whatsapp-ia-integrator/internal/phone"
 	"whatsapp-ia-integrator/internal/queue"
+	"whatsapp-ia-integrator/internal/routing"
 	"whatsapp-ia-integrator/internal/session"
+	"whatsapp-ia-integrator/internal/suppress"
+	"whatsapp-ia-integrator/internal/transcript"
 	"whatsapp-ia-integrator/internal/whatsapp"
 )
 
@@ -28,20 +36,81 @@ func main() {
 	if err != nil {
 		log.Fatalf("erro carregando config: %v", err)
 	}
+	phone.SetDefaultCountry(cfg.WhatsApp.DefaultCountry)
 
 	csaClient := csa.NewClient(cfg.CSA)
 	chatvoltClient := chatvolt.NewClient(cfg.IA.Chatvolt)
-	sessionManager := session.NewManager(10 * time.Minute)
+	sessionManager := session.NewManager(10*time.Minute, cfg.WhatsApp.Handoff.IdleTimeout())
+	sessionEvents, err := setupSessionSinks(cfg.SessionEvents, sessionManager)
+	if err != nil {
+		log.Fatalf("erro configurando eventos de sessão: %v", err)
+	}
 	jobManager := queue.NewJobManager()
+	transcripts, err := transcript.NewStore(cfg.Transcripts.Path, cfg.Transcripts.MaxPerPhone)
+	if err != nil {
+		log.Fatalf("erro abrindo transcrições: %v", err)
+	}
 
-	outbox := queue.NewOutbox(csaClient, *workers, jobManager)
+	suppressions, err := suppress.NewList(cfg.Suppression.Path)
+	if err != nil {
+		log.Fatalf("erro abrindo lista de supressão: %v", err)
+	}
+
+	outbox := queue.NewOutbox(csaClient, *workers, jobManager, transcripts, suppressions)
 	outbox.Start()
 
-	handler := whatsapp.NewHandler(chatvoltClient, sessionManager, outbox, jobManager)
+	inboundTracker := queue.NewInboundTracker(24*time.Hour, 10000)
+	dedupStore, err := dedup.NewStore(cfg.WhatsApp.Dedup.Window(), cfg.WhatsApp.Dedup.MaxEntries, cfg.WhatsApp.Dedup.Path)
+	if err != nil {
+		log.Fatalf("erro abrindo store de deduplicação: %v", err)
+	}
+	businessHours, err := calendar.New(cfg.BusinessHours)
+	if err != nil {
+		log.Fatalf("erro configurando horário de atendimento: %v", err)
+	}
+	router, err := routing.New(cfg.IA.Routes)
+	if err != nil {
+		log.Fatalf("erro configurando rotas da IA: %v", err)
+	}
+	if cfg.IA.Chatvolt.Callback.Enabled && cfg.IA.Chatvolt.Callback.PublicURL == "" {
+		log.Fatalf("ia.chatvolt.callback.public_url é obrigatório com o modo callback")
+	}
+	handler := whatsapp.NewHandler(chatvoltClient, sessionManager, outbox, jobManager, transcripts, inboundTracker, dedupStore, suppressions, businessHours, router, cfg.WhatsApp, cfg.BusinessHours, cfg.CSA.Channels, cfg.IA.Chatvolt)
+	handler.Start()
+
+	verifier, err := whatsapp.NewVerifier(cfg.WhatsApp.Auth)
+	if err != nil {
+		log.Fatalf("erro configurando autenticação do webhook: %v", err)
+	}
+	if !verifier.Enabled() {
+		log.Println("whatsapp.auth não configurado: webhook aceita qualquer origem")
+	}
+	if cfg.WhatsApp.Auth.HMACSecret != "" && cfg.WhatsApp.Providers.MetaAppSecret == "" {
+		// a rota da Meta dispensa o HMAC genérico e valida com o app secret
+		log.Fatalf("whatsapp.auth.hmac_secret exige whatsapp.providers.meta_app_secret: sem ele /whatsapp/webhook/meta aceitaria webhooks sem assinatura")
+	}
 
 	mux := http.NewServeMux()
-	mux.Handle("/whatsapp/webhook", handler)
+	mux.Handle("/whatsapp/webhook", verifier.Wrap(handler))
+	mux.Handle("/whatsapp/webhook/", verifier.Wrap(handler))
+	if cfg.IA.Chatvolt.Callback.Enabled {
+		mux.HandleFunc(whatsapp.CallbackPath, handler.ServeCallback)
+	}
 	mux.Handle("/jobs/", queue.NewJobStatusHandler(jobManager))
+	mux.Handle("/inbound/", queue.NewInboundStatusHandler(inboundTracker))
+	mux.Handle("/debug/vars", expvar.Handler())
+	if cfg.Admin.Token != "" {
+		sessionsAdmin := admin.RequireToken(cfg.Admin.Token, session.NewAdminHandler(sessionManager))
+		mux.Handle("/admin/sessions", sessionsAdmin)
+		mux.Handle("/admin/sessions/", sessionsAdmin)
+		mux.Handle("/admin/transcripts/", admin.RequireToken(cfg.Admin.Token, transcript.NewHandler(transcripts)))
+		mux.Handle("/admin/session-events", admin.RequireToken(cfg.Admin.Token, session.NewEventsHandler(sessionEvents)))
+		suppressionsAdmin := admin.RequireToken(cfg.Admin.Token, suppress.NewAdminHandler(suppressions))
+		mux.Handle("/admin/suppressions", suppressionsAdmin)
+		mux.Handle("/admin/suppressions/", suppressionsAdmin)
+	} else {
+		log.Println("admin.token não configurado: endpoints administrativos desabilitados")
+	}
 	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok"))
@@ -71,6 +140,37 @@ func main() {
 		log.Printf("erro encerrando servidor: %v", err)
 	}
 
+	handler.Stop()
 	sessionManager.Stop()
 	outbox.Stop()
+	if err := transcripts.Close(); err != nil {
+		log.Printf("erro fechando transcrições: %v", err)
+	}
+	if err := dedupStore.Close(); err != nil {
+		log.Printf("erro fechando store de deduplicação: %v", err)
+	}
+	if err := suppressions.Close(); err != nil {
+		log.Printf("erro fechando lista de supressão: %v", err)
+	}
+}
+
+// setupSessionSinks registra os sinks configurados e retorna o ring buffer usado pela API.
+func setupSessionSinks(cfg config.SessionEventsConfig, mgr *session.Manager) (*session.RingSink, error) {
+	ring := session.NewRingSink(cfg.RingSize)
+	mgr.AddSink(ring)
+
+	if cfg.File.Path != "" {
+		fileSink, err := session.NewFileSink(cfg.File.Path, int64(cfg.File.MaxSizeMB)*1024*1024, cfg.File.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		mgr.AddSink(fileSink)
+	}
+
+	if cfg.Webhook.URL != "" {
+		interval := time.Duration(cfg.Webhook.FlushIntervalMS) * time.Millisecond
+		mgr.AddSink(session.NewWebhookSink(cfg.Webhook.URL, cfg.Webhook.Headers, cfg.Webhook.BatchSize, interval))
+	}
+
+	return ring, nil
 }
@@ -0,0 +1,113 @@
+package adapter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"whatsapp-ia-integrator/internal/model"
+)
+
+// InboundAdapter converte o webhook de um provedor em eventos normalizados
+// (mensagens e, com Event "status", atualizações de envio).
+type InboundAdapter interface {
+	Name() string
+	// Match reconhece o formato pelo corpo já decodificado (auto-detecção).
+	Match(probe map[string]any) bool
+	Parse(body []byte) ([]model.InboundWebhook, error)
+}
+
+// ChallengeVerifier é implementado por provedores que validam o endpoint com
+// um GET (ex.: hub.challenge da Cloud API).
+type ChallengeVerifier interface {
+	VerifyChallenge(query url.Values) (string, bool)
+}
+
+// SignatureVerifier é implementado por provedores que assinam o webhook com
+// esquema próprio (ex.: X-Hub-Signature-256 da Cloud API).
+type SignatureVerifier interface {
+	VerifySignature(header http.Header, body []byte) bool
+}
+
+// Registry guarda os adapters disponíveis. A ordem define a prioridade da
+// auto-detecção; o último (CSA) é usado quando nenhum reconhece o corpo.
+type Registry struct {
+	adapters []InboundAdapter
+	// preferred fixa o formato dos webhooks sem provedor no endpoint.
+	preferred InboundAdapter
+}
+
+// NewRegistry monta o registro com os provedores suportados. defaultName
+// fixa o formato quando o endpoint não informa um ("auto" ou vazio detecta).
+func NewRegistry(defaultName, metaVerifyToken, metaAppSecret string) *Registry {
+	r := &Registry{
+		adapters: []InboundAdapter{NewMeta(metaVerifyToken, metaAppSecret), NewGupshup(), NewCSA()},
+	}
+	r.preferred = r.Named(defaultName)
+	return r
+}
+
+// Named retorna o adapter pelo nome (csa, gupshup, meta) ou nil.
+func (r *Registry) Named(name string) InboundAdapter {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, a := range r.adapters {
+		if a.Name() == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Detect escolhe o adapter pelo formato do corpo, a menos que um formato
+// padrão tenha sido configurado.
+func (r *Registry) Detect(body []byte) InboundAdapter {
+	if r.preferred != nil {
+		return r.preferred
+	}
+
+	fallback := r.adapters[len(r.adapters)-1]
+	var probe map[string]any
+	if err := json.Unmarshal(body, &probe); err != nil {
+		return fallback
+	}
+	for _, a := range r.adapters {
+		if a.Match(probe) {
+			return a
+		}
+	}
+	return fallback
+}
+
+func str(v any) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return ""
+}
+
+func obj(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func list(v any) []any {
+	l, _ := v.([]any)
+	return l
+}
+
+// timestamp aceita segundos/milissegundos como número ou string.
+func timestamp(v any) int64 {
+	switch t := v.(type) {
+	case float64:
+		return int64(t)
+	case string:
+		n, _ := strconv.ParseInt(strings.TrimSpace(t), 10, 64)
+		return n
+	}
+	return 0
+}
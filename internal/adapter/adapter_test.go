@@ -0,0 +1,282 @@
+package adapter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"whatsapp-ia-integrator/internal/model"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+// withoutRaw descarta o payload bruto, que só repete o fixture.
+func withoutRaw(events []model.InboundWebhook) []model.InboundWebhook {
+	out := make([]model.InboundWebhook, len(events))
+	for i, e := range events {
+		e.RawPayload = nil
+		out[i] = e
+	}
+	return out
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		fixture string
+		adapter InboundAdapter
+		want    []model.InboundWebhook
+	}{
+		{
+			fixture: "csa_message.json",
+			adapter: NewCSA(),
+			want: []model.InboundWebhook{{
+				Channel: "whatsapp", Event: "message", MessageID: "csa-msg-1", PlatformMessageID: "wamid.csa1",
+				PlatformID: "plat-1", From: "5511987654321", To: "5511300000000", Type: "text",
+				MessageText: "Olá, quero saber do meu pedido", Timestamp: 1760850000,
+				RawContact: map[string]string{"name": "Maria", "phone": "5511987654321"},
+			}},
+		},
+		{
+			fixture: "gupshup_message.json",
+			adapter: NewGupshup(),
+			want: []model.InboundWebhook{{
+				Channel: "whatsapp", Event: "message", MessageID: "ABEGVUiZKQggAhAXRk1EOTQ2Q0VFQzU2",
+				PlatformMessageID: "ABEGVUiZKQggAhAXRk1EOTQ2Q0VFQzU2", PlatformID: "LojaBot",
+				From: "5511987654321", Type: "text", MessageText: "Oi, tudo bem?", Timestamp: 1760850000123,
+				RawContact: map[string]string{"name": "João", "phone": "5511987654321"},
+			}},
+		},
+		{
+			fixture: "gupshup_message_event.json",
+			adapter: NewGupshup(),
+			want: []model.InboundWebhook{{
+				Channel: "whatsapp", Event: "status", MessageID: "ee4a68a0-1203-4c85-8dc3-49d0b3226a35",
+				PlatformMessageID: "wamid.HBgNNTUxMTk4NzY1NDMyMRUCABEYEjQ", PlatformID: "LojaBot",
+				To: "5511987654321", Status: "delivered", Timestamp: 1760850001456,
+			}},
+		},
+		{
+			fixture: "gupshup_user_event.json",
+			adapter: NewGupshup(),
+			want:    []model.InboundWebhook{},
+		},
+		{
+			fixture: "meta_messages.json",
+			adapter: NewMeta("", ""),
+			want: []model.InboundWebhook{{
+				Channel: "whatsapp", Event: "message", MessageID: "wamid.HBgNNTUxMTk4NzY1NDMyMRUCABIYFjNFQjA",
+				PlatformMessageID: "wamid.HBgNNTUxMTk4NzY1NDMyMRUCABIYFjNFQjA", PlatformID: "106540352242922",
+				From: "5511987654321", To: "15550783881", Type: "text",
+				MessageText: "Qual o horário de funcionamento?", Timestamp: 1760850000,
+				RawContact: map[string]string{"name": "Ana Souza", "phone": "5511987654321"},
+			}},
+		},
+		{
+			fixture: "meta_statuses.json",
+			adapter: NewMeta("", ""),
+			want: []model.InboundWebhook{{
+				Channel: "whatsapp", Event: "status", MessageID: "wamid.HBgNNTUxMTk4NzY1NDMyMRUCABEYEjQ",
+				PlatformMessageID: "wamid.HBgNNTUxMTk4NzY1NDMyMRUCABEYEjQ", PlatformID: "106540352242922",
+				To: "5511987654321", Status: "read", Timestamp: 1760850010,
+			}},
+		},
+		{
+			fixture: "meta_batch.json",
+			adapter: NewMeta("", ""),
+			want: []model.InboundWebhook{
+				{
+					Channel: "whatsapp", Event: "message", MessageID: "wamid.batch1", PlatformMessageID: "wamid.batch1",
+					PlatformID: "106540352242922", From: "5511987654321", To: "15550783881", Type: "text",
+					MessageText: "primeira", Timestamp: 1760850000,
+					RawContact: map[string]string{"name": "Ana Souza", "phone": "5511987654321"},
+				},
+				{
+					Channel: "whatsapp", Event: "message", MessageID: "wamid.batch2", PlatformMessageID: "wamid.batch2",
+					PlatformID: "106540352242922", From: "5511987654321", To: "15550783881", Type: "image",
+					Timestamp:  1760850001,
+					RawContact: map[string]string{"name": "Ana Souza", "phone": "5511987654321"},
+				},
+				{
+					Channel: "whatsapp", Event: "status", MessageID: "wamid.out1", PlatformMessageID: "wamid.out1",
+					PlatformID: "206540352242933", To: "5521998877665", Status: "delivered", Timestamp: 1760850005,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			events, err := tt.adapter.Parse(fixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := withoutRaw(events); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%s)\n got %+v\nwant %+v", tt.fixture, got, tt.want)
+			}
+			for _, e := range events {
+				if e.RawPayload == nil && tt.adapter.Name() != "csa" {
+					t.Errorf("evento %s sem RawPayload", e.MessageID)
+				}
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, a := range []InboundAdapter{NewCSA(), NewGupshup(), NewMeta("", "")} {
+		if _, err := a.Parse([]byte("{")); err == nil {
+			t.Errorf("%s: esperava erro com JSON inválido", a.Name())
+		}
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		defaultName string
+		want        string
+	}{
+		{"csa_message.json", "", "csa"},
+		{"gupshup_message.json", "", "gupshup"},
+		{"gupshup_message_event.json", "auto", "gupshup"},
+		{"meta_messages.json", "", "meta"},
+		{"meta_batch.json", "", "meta"},
+		{"meta_messages.json", "csa", "csa"},
+		{"csa_message.json", "gupshup", "gupshup"},
+	}
+
+	for _, tt := range tests {
+		r := NewRegistry(tt.defaultName, "", "")
+		if got := r.Detect(fixture(t, tt.fixture)).Name(); got != tt.want {
+			t.Errorf("Detect(%s, default=%q) = %s, want %s", tt.fixture, tt.defaultName, got, tt.want)
+		}
+	}
+
+	r := NewRegistry("", "", "")
+	if got := r.Detect([]byte("não é json")).Name(); got != "csa" {
+		t.Errorf("Detect(inválido) = %s, want csa", got)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	adapters := []InboundAdapter{NewCSA(), NewGupshup(), NewMeta("", "")}
+	fixtures := map[string]string{
+		"csa_message.json":     "csa",
+		"gupshup_message.json": "gupshup",
+		"meta_messages.json":   "meta",
+	}
+
+	for name, owner := range fixtures {
+		r := NewRegistry("", "", "")
+		var probe map[string]any
+		if err := json.Unmarshal(fixture(t, name), &probe); err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range adapters {
+			if a.Name() == owner && !a.Match(probe) {
+				t.Errorf("%s não reconheceu %s", a.Name(), name)
+			}
+		}
+		if got := r.Detect(fixture(t, name)).Name(); got != owner {
+			t.Errorf("Detect(%s) = %s, want %s", name, got, owner)
+		}
+	}
+}
+
+func TestNamed(t *testing.T) {
+	r := NewRegistry("", "", "")
+	for _, name := range []string{"csa", "gupshup", "meta", " META "} {
+		if r.Named(name) == nil {
+			t.Errorf("Named(%q) = nil", name)
+		}
+	}
+	if r.Named("telegram") != nil {
+		t.Error("Named(telegram) deveria ser nil")
+	}
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	query, err := url.ParseQuery(strings.TrimSpace(string(fixture(t, "meta_challenge.query"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		query func(url.Values) url.Values
+		want  string
+		ok    bool
+	}{
+		{"token correto", "segredo-verificacao", nil, "1158201444", true},
+		{"token errado", "outro", nil, "", false},
+		{"sem token configurado", "", nil, "", false},
+		{"modo diferente", "segredo-verificacao", func(q url.Values) url.Values {
+			q.Set("hub.mode", "unsubscribe")
+			return q
+		}, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := url.Values{}
+			for k, v := range query {
+				q[k] = append([]string(nil), v...)
+			}
+			if tt.query != nil {
+				q = tt.query(q)
+			}
+			got, ok := NewMeta(tt.token, "").VerifyChallenge(q)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("VerifyChallenge = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := fixture(t, "meta_messages.json")
+	mac := hmac.New(sha256.New, []byte("app-secret"))
+	mac.Write(body)
+	valid := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		want      bool
+	}{
+		{"assinatura válida", "app-secret", valid, true},
+		{"assinatura de outro segredo", "outro-secret", valid, false},
+		{"sem assinatura", "app-secret", "", false},
+		{"hex inválido", "app-secret", "sha256=zz", false},
+		{"sem app secret configurado", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.signature != "" {
+				header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			if got := NewMeta("", tt.secret).VerifySignature(header, body); got != tt.want {
+				t.Errorf("VerifySignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
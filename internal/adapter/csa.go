@@ -0,0 +1,30 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"whatsapp-ia-integrator/internal/model"
+)
+
+// CSA entende o envelope da CSA (model.InboundWebhook), formato original do webhook.
+type CSA struct{}
+
+func NewCSA() *CSA { return &CSA{} }
+
+func (*CSA) Name() string { return "csa" }
+
+func (*CSA) Match(probe map[string]any) bool {
+	_, hasFrom := probe["from"]
+	_, hasEvent := probe["event"]
+	_, hasRaw := probe["rawPayload"]
+	return hasFrom || hasEvent || hasRaw
+}
+
+func (*CSA) Parse(body []byte) ([]model.InboundWebhook, error) {
+	var payload model.InboundWebhook
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("decode csa payload: %w", err)
+	}
+	return []model.InboundWebhook{payload}, nil
+}
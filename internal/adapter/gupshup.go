@@ -0,0 +1,84 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"whatsapp-ia-integrator/internal/model"
+)
+
+// Gupshup entende o webhook nativo do Gupshup (v2):
+//
+//	{"app":"...","timestamp":...,"type":"message","payload":{"id","source","type","payload":{...},"sender":{...}}}
+//	{"app":"...","timestamp":...,"type":"message-event","payload":{"id","gsId","type":"delivered","destination"}}
+//
+// Eventos de usuário (opt-in/out do Gupshup) e de cobrança são ignorados.
+type Gupshup struct{}
+
+func NewGupshup() *Gupshup { return &Gupshup{} }
+
+func (*Gupshup) Name() string { return "gupshup" }
+
+func (*Gupshup) Match(probe map[string]any) bool {
+	_, hasApp := probe["app"]
+	_, hasPayload := probe["payload"].(map[string]any)
+	return hasApp && hasPayload
+}
+
+func (*Gupshup) Parse(body []byte) ([]model.InboundWebhook, error) {
+	var envelope struct {
+		App       string         `json:"app"`
+		Timestamp int64          `json:"timestamp"`
+		Type      string         `json:"type"`
+		Payload   map[string]any `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("decode gupshup payload: %w", err)
+	}
+
+	p := envelope.Payload
+	switch envelope.Type {
+	case "message":
+		sender := obj(p["sender"])
+		from := str(sender["phone"])
+		if from == "" {
+			from = str(p["source"])
+		}
+
+		msg := model.InboundWebhook{
+			Channel:           "whatsapp",
+			Event:             "message",
+			MessageID:         str(p["id"]),
+			PlatformMessageID: str(p["id"]),
+			PlatformID:        envelope.App,
+			From:              from,
+			Type:              str(p["type"]),
+			Timestamp:         envelope.Timestamp,
+			RawPayload:        p,
+			RawContact:        map[string]string{"name": str(sender["name"]), "phone": from},
+		}
+		if msg.Type == "text" {
+			msg.MessageText = str(obj(p["payload"])["text"])
+		}
+		return []model.InboundWebhook{msg}, nil
+
+	case "message-event":
+		// gsId é o id devolvido no envio; id pode ser o wamid da Meta
+		id := str(p["gsId"])
+		if id == "" {
+			id = str(p["id"])
+		}
+		return []model.InboundWebhook{{
+			Channel:           "whatsapp",
+			Event:             "status",
+			MessageID:         id,
+			PlatformMessageID: str(p["id"]),
+			PlatformID:        envelope.App,
+			To:                str(p["destination"]),
+			Status:            str(p["type"]),
+			Timestamp:         envelope.Timestamp,
+			RawPayload:        p,
+		}}, nil
+	}
+	return nil, nil
+}
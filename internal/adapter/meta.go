@@ -0,0 +1,147 @@
+package adapter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"whatsapp-ia-integrator/internal/model"
+)
+
+// Meta entende o webhook da WhatsApp Cloud API (entry[].changes[].value com
+// messages, contacts e statuses), responde à verificação hub.challenge e
+// valida o X-Hub-Signature-256 quando o app secret está configurado.
+type Meta struct {
+	verifyToken string
+	appSecret   string
+}
+
+func NewMeta(verifyToken, appSecret string) *Meta {
+	return &Meta{verifyToken: verifyToken, appSecret: appSecret}
+}
+
+func (*Meta) Name() string { return "meta" }
+
+func (*Meta) Match(probe map[string]any) bool {
+	_, hasEntry := probe["entry"].([]any)
+	return hasEntry && str(probe["object"]) != ""
+}
+
+// VerifyChallenge valida hub.mode=subscribe e hub.verify_token e devolve o hub.challenge.
+func (m *Meta) VerifyChallenge(query url.Values) (string, bool) {
+	if m.verifyToken == "" || query.Get("hub.mode") != "subscribe" {
+		return "", false
+	}
+	if subtle.ConstantTimeCompare([]byte(query.Get("hub.verify_token")), []byte(m.verifyToken)) != 1 {
+		return "", false
+	}
+	return query.Get("hub.challenge"), true
+}
+
+// VerifySignature confere HMAC-SHA256(app secret, corpo) no header
+// X-Hub-Signature-256 ("sha256=<hex>"). Sem app secret, aceita.
+func (m *Meta) VerifySignature(header http.Header, body []byte) bool {
+	if m.appSecret == "" {
+		return true
+	}
+
+	signature := strings.TrimPrefix(strings.TrimSpace(header.Get("X-Hub-Signature-256")), "sha256=")
+	provided, err := hex.DecodeString(signature)
+	if err != nil || len(provided) == 0 {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(m.appSecret))
+	mac.Write(body)
+	return hmac.Equal(provided, mac.Sum(nil))
+}
+
+func (*Meta) Parse(body []byte) ([]model.InboundWebhook, error) {
+	var envelope struct {
+		Object string `json:"object"`
+		Entry  []struct {
+			Changes []struct {
+				Field string         `json:"field"`
+				Value map[string]any `json:"value"`
+			} `json:"changes"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("decode meta payload: %w", err)
+	}
+
+	var events []model.InboundWebhook
+	for _, entry := range envelope.Entry {
+		for _, change := range entry.Changes {
+			if change.Field != "messages" || change.Value == nil {
+				continue
+			}
+			events = append(events, metaEvents(change.Value)...)
+		}
+	}
+	return events, nil
+}
+
+func metaEvents(value map[string]any) []model.InboundWebhook {
+	metadata := obj(value["metadata"])
+	to := str(metadata["display_phone_number"])
+	platformID := str(metadata["phone_number_id"])
+
+	names := make(map[string]string)
+	for _, c := range list(value["contacts"]) {
+		contact := obj(c)
+		names[str(contact["wa_id"])] = str(obj(contact["profile"])["name"])
+	}
+
+	var events []model.InboundWebhook
+	for _, item := range list(value["messages"]) {
+		m := obj(item)
+		if m == nil {
+			continue
+		}
+		from := str(m["from"])
+
+		msg := model.InboundWebhook{
+			Channel:           "whatsapp",
+			Event:             "message",
+			MessageID:         str(m["id"]),
+			PlatformMessageID: str(m["id"]),
+			PlatformID:        platformID,
+			From:              from,
+			To:                to,
+			Type:              str(m["type"]),
+			Timestamp:         timestamp(m["timestamp"]),
+			RawPayload:        m,
+			RawContact:        map[string]string{"name": names[from], "phone": from},
+		}
+		if msg.Type == "text" {
+			msg.MessageText = str(obj(m["text"])["body"])
+		}
+		events = append(events, msg)
+	}
+
+	for _, item := range list(value["statuses"]) {
+		s := obj(item)
+		if s == nil {
+			continue
+		}
+		events = append(events, model.InboundWebhook{
+			Channel:           "whatsapp",
+			Event:             "status",
+			MessageID:         str(s["id"]),
+			PlatformMessageID: str(s["id"]),
+			PlatformID:        platformID,
+			To:                str(s["recipient_id"]),
+			Status:            str(s["status"]),
+			Timestamp:         timestamp(s["timestamp"]),
+			RawPayload:        s,
+		})
+	}
+	return events
+}
@@ -0,0 +1,261 @@
+package calendar
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	// imagens de container costumam não ter o zoneinfo do sistema
+	_ "time/tzdata"
+
+	"whatsapp-ia-integrator/internal/config"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "domingo": time.Sunday, "dom": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday, "segunda": time.Monday, "seg": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "terca": time.Tuesday, "terça": time.Tuesday, "ter": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "quarta": time.Wednesday, "qua": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "quinta": time.Thursday, "qui": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "sexta": time.Friday, "sex": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "sabado": time.Saturday, "sábado": time.Saturday, "sab": time.Saturday,
+}
+
+var weekdayNames = [...]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"}
+
+// window é um intervalo do dia em minutos desde 00:00, [start, end).
+type window struct {
+	start, end int
+}
+
+// Calendar responde se o atendimento está aberto em um instante.
+type Calendar struct {
+	loc       *time.Location
+	week      map[time.Weekday][]window
+	holidays  map[string]bool // 2006-01-02
+	recurring map[string]bool // 01-02
+	// closed são as janelas de out_of_hours_windows, na ordem da configuração.
+	closed []closedWindow
+}
+
+// closedWindow restringe uma política de fora do expediente; campos vazios não restringem.
+type closedWindow struct {
+	days     map[time.Weekday]bool
+	hours    []window
+	holidays bool
+}
+
+// New valida a configuração. Sem schedule, o calendário fica sempre aberto.
+func New(cfg config.BusinessHoursConfig) (*Calendar, error) {
+	loc := time.Local
+	if cfg.Timezone != "" {
+		l, err := time.LoadLocation(cfg.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("timezone %q: %w", cfg.Timezone, err)
+		}
+		loc = l
+	}
+
+	c := &Calendar{
+		loc:       loc,
+		week:      make(map[time.Weekday][]window),
+		holidays:  make(map[string]bool),
+		recurring: make(map[string]bool),
+	}
+
+	for day, ranges := range cfg.Schedule {
+		wd, ok := weekdays[strings.ToLower(strings.TrimSpace(day))]
+		if !ok {
+			return nil, fmt.Errorf("dia da semana inválido: %q", day)
+		}
+		for _, r := range ranges {
+			w, err := parseWindow(r)
+			if err != nil {
+				return nil, fmt.Errorf("horário de %s: %w", day, err)
+			}
+			c.week[wd] = append(c.week[wd], w)
+		}
+		sort.Slice(c.week[wd], func(i, j int) bool { return c.week[wd][i].start < c.week[wd][j].start })
+	}
+
+	for _, h := range cfg.Holidays {
+		h = strings.TrimSpace(h)
+		if _, err := time.Parse("2006-01-02", h); err == nil {
+			c.holidays[h] = true
+			continue
+		}
+		if _, err := time.Parse("01-02", h); err == nil {
+			c.recurring[h] = true
+			continue
+		}
+		return nil, fmt.Errorf("feriado inválido: %q", h)
+	}
+
+	for i, wcfg := range cfg.OutOfHoursWindows {
+		cw := closedWindow{holidays: wcfg.Holidays}
+		for _, day := range wcfg.Days {
+			wd, ok := weekdays[strings.ToLower(strings.TrimSpace(day))]
+			if !ok {
+				return nil, fmt.Errorf("out_of_hours_windows[%d]: dia da semana inválido: %q", i, day)
+			}
+			if cw.days == nil {
+				cw.days = make(map[time.Weekday]bool)
+			}
+			cw.days[wd] = true
+		}
+		for _, r := range wcfg.Hours {
+			w, err := parseWindow(r)
+			if err != nil {
+				return nil, fmt.Errorf("out_of_hours_windows[%d]: %w", i, err)
+			}
+			cw.hours = append(cw.hours, w)
+		}
+		c.closed = append(c.closed, cw)
+	}
+
+	return c, nil
+}
+
+// Enabled indica se há expediente configurado.
+func (c *Calendar) Enabled() bool {
+	return c != nil && len(c.week) > 0
+}
+
+// Open informa se t está dentro do expediente.
+func (c *Calendar) Open(t time.Time) bool {
+	if !c.Enabled() {
+		return true
+	}
+
+	t = t.In(c.loc)
+	if c.holiday(t) {
+		return false
+	}
+
+	return inWindows(c.week[t.Weekday()], t.Hour()*60+t.Minute())
+}
+
+func inWindows(windows []window, minute int) bool {
+	for _, w := range windows {
+		if minute >= w.start && minute < w.end {
+			return true
+		}
+	}
+	return false
+}
+
+// ClosedWindow retorna o índice da primeira janela de out_of_hours_windows que
+// cobre t, ou -1 para a política padrão. Só faz sentido com o atendimento fechado.
+func (c *Calendar) ClosedWindow(t time.Time) int {
+	if c == nil {
+		return -1
+	}
+
+	t = t.In(c.loc)
+	minute := t.Hour()*60 + t.Minute()
+	for i, cw := range c.closed {
+		if cw.holidays && !c.holiday(t) {
+			continue
+		}
+		if cw.days != nil && !cw.days[t.Weekday()] {
+			continue
+		}
+		if len(cw.hours) > 0 && !inWindows(cw.hours, minute) {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// NextOpen retorna a próxima abertura a partir de t (até duas semanas à frente).
+func (c *Calendar) NextOpen(t time.Time) (time.Time, bool) {
+	if !c.Enabled() {
+		return t, true
+	}
+
+	t = t.In(c.loc)
+	for d := 0; d <= 14; d++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()+d, 0, 0, 0, 0, c.loc)
+		if c.holiday(day) {
+			continue
+		}
+		for _, w := range c.week[day.Weekday()] {
+			start := day.Add(time.Duration(w.start) * time.Minute)
+			end := day.Add(time.Duration(w.end) * time.Minute)
+			if !end.After(t) {
+				continue
+			}
+			if start.Before(t) {
+				return t, true
+			}
+			return start, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Describe formata a próxima abertura para mensagens ao cliente:
+// "hoje às 14:00", "amanhã às 08:00" ou "segunda-feira às 08:00".
+func (c *Calendar) Describe(now time.Time) string {
+	next, ok := c.NextOpen(now)
+	if !ok {
+		return "em breve"
+	}
+
+	now = now.In(c.loc)
+	next = next.In(c.loc)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, c.loc)
+	days := int(math.Round(time.Date(next.Year(), next.Month(), next.Day(), 0, 0, 0, 0, c.loc).Sub(today).Hours() / 24))
+
+	var when string
+	switch {
+	case days == 0:
+		when = "hoje"
+	case days == 1:
+		when = "amanhã"
+	case days < 7:
+		when = weekdayNames[next.Weekday()]
+	default:
+		when = next.Format("02/01")
+	}
+	return when + " às " + next.Format("15:04")
+}
+
+func (c *Calendar) holiday(t time.Time) bool {
+	return c.holidays[t.Format("2006-01-02")] || c.recurring[t.Format("01-02")]
+}
+
+func parseWindow(raw string) (window, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(raw), "-")
+	if !ok {
+		return window{}, fmt.Errorf("intervalo inválido %q, use 08:00-18:00", raw)
+	}
+
+	start, err := parseClock(from)
+	if err != nil {
+		return window{}, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return window{}, err
+	}
+	if end <= start {
+		return window{}, fmt.Errorf("intervalo %q termina antes de começar", raw)
+	}
+	return window{start: start, end: end}, nil
+}
+
+func parseClock(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "24:00" {
+		return 24 * 60, nil
+	}
+	t, err := time.Parse("15:04", raw)
+	if err != nil {
+		return 0, fmt.Errorf("horário inválido %q", raw)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
@@ -0,0 +1,56 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"whatsapp-ia-integrator/internal/config"
+)
+
+func TestClosedWindow(t *testing.T) {
+	c, err := New(config.BusinessHoursConfig{
+		Timezone: "America/Sao_Paulo",
+		Schedule: map[string][]string{"seg": {"08:00-18:00"}, "sab": {"08:00-12:00"}},
+		Holidays: []string{"12-25"},
+		OutOfHoursWindows: []config.OutOfHoursWindow{
+			{Holidays: true},
+			{Days: []string{"sabado", "domingo"}},
+			{Hours: []string{"18:00-22:00"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loc, _ := time.LoadLocation("America/Sao_Paulo")
+	tests := []struct {
+		name string
+		at   time.Time
+		want int
+	}{
+		{"feriado", time.Date(2026, 12, 25, 20, 0, 0, 0, loc), 0},
+		{"domingo", time.Date(2026, 10, 18, 10, 0, 0, 0, loc), 1},
+		{"noite de segunda", time.Date(2026, 10, 19, 19, 30, 0, 0, loc), 2},
+		{"madrugada de segunda", time.Date(2026, 10, 19, 3, 0, 0, 0, loc), -1},
+	}
+	for _, tt := range tests {
+		if got := c.ClosedWindow(tt.at); got != tt.want {
+			t.Errorf("%s: ClosedWindow = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	if got := (*Calendar)(nil).ClosedWindow(time.Now()); got != -1 {
+		t.Errorf("calendario nil = %d, want -1", got)
+	}
+}
+
+func TestClosedWindowInvalid(t *testing.T) {
+	for _, w := range []config.OutOfHoursWindow{
+		{Days: []string{"feriadao"}},
+		{Hours: []string{"22:00-06:00"}},
+	} {
+		if _, err := New(config.BusinessHoursConfig{OutOfHoursWindows: []config.OutOfHoursWindow{w}}); err == nil {
+			t.Errorf("janela %+v aceita, want erro", w)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
@@ -16,13 +17,17 @@ const baseURL = "https://api.chatvolt.ai"
 // Client envia consultas para o Chatvolt.
 type Client struct {
 	httpClient *http.Client
-	cfg        config.ChatvoltConfig
+	// streamClient não tem timeout total: a geração pode demorar e o prazo
+	// vem do contexto.
+	streamClient *http.Client
+	cfg          config.ChatvoltConfig
 }
 
 func NewClient(cfg config.ChatvoltConfig) *Client {
 	return &Client{
-		httpClient: &http.Client{Timeout: 30 * time.Second},
-		cfg:        cfg,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		streamClient: &http.Client{},
+		cfg:          cfg,
 	}
 }
 
@@ -39,6 +44,9 @@ type QueryRequest struct {
 	Filters        map[string]any `json:"filters,omitempty"`
 	Context        map[string]any `json:"context,omitempty"`
 	CallbackURL    string         `json:"callbackURL,omitempty"`
+
+	// AgentID substitui o agente configurado nesta consulta (não vai no corpo).
+	AgentID string `json:"-"`
 }
 
 // Contact representa dados do remetente enviados para IA.
@@ -61,41 +69,75 @@ type QueryResponse struct {
 
 // Query envia uma mensagem de texto e retorna a resposta da IA.
 func (c *Client) Query(ctx context.Context, payload QueryRequest) (*QueryResponse, error) {
-	if payload.Query == "" {
-		return nil, fmt.Errorf("query text vazio")
+	payload.Streaming = false
+	resp, err := c.do(ctx, c.httpClient, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var parsed QueryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("decode chatvolt response: %w", err)
+	}
+
+	return &parsed, nil
+}
+
+// QueryAsync envia a consulta com CallbackURL e retorna sem esperar a
+// resposta, que o Chatvolt entrega depois no callback.
+func (c *Client) QueryAsync(ctx context.Context, payload QueryRequest) error {
+	if payload.CallbackURL == "" {
+		return fmt.Errorf("callbackURL vazio")
 	}
 
 	payload.Streaming = false
+	resp, err := c.do(ctx, c.httpClient, payload)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// do envia a consulta e devolve a resposta já validada; o chamador fecha o corpo.
+func (c *Client) do(ctx context.Context, httpClient *http.Client, payload QueryRequest) (*http.Response, error) {
+	if payload.Query == "" {
+		return nil, fmt.Errorf("query text vazio")
+	}
 
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("marshal chatvolt payload: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/agents/%s/query", baseURL, c.cfg.AgentID)
+	agentID := c.cfg.AgentID
+	if payload.AgentID != "" {
+		agentID = payload.AgentID
+	}
+
+	url := fmt.Sprintf("%s/agents/%s/query", baseURL, agentID)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("new chatvolt request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
 	req.Header.Set("Content-Type", "application/json")
+	if payload.Streaming {
+		req.Header.Set("Accept", "text/event-stream")
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("call chatvolt: %w", err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
 		var raw map[string]any
 		_ = json.NewDecoder(resp.Body).Decode(&raw)
 		return nil, fmt.Errorf("chatvolt error: status=%d body=%v", resp.StatusCode, raw)
 	}
 
-	var parsed QueryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
-		return nil, fmt.Errorf("decode chatvolt response: %w", err)
-	}
-
-	return &parsed, nil
+	return resp, nil
 }
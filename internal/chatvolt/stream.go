@@ -0,0 +1,111 @@
+package chatvolt
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// eventos SSE do Chatvolt: tokens chegam como "data:" simples, a resposta
+// completa (conversationId, metadata...) vem no evento endpoint_response e o
+// fim é sinalizado por "data: [DONE]".
+const (
+	eventEndpointResponse = "endpoint_response"
+	streamDone            = "[DONE]"
+)
+
+// StreamEvent é um item do stream: um trecho da resposta (Token), a resposta
+// final (Response) ou o erro que interrompeu a leitura (Err).
+type StreamEvent struct {
+	Token    string
+	Response *QueryResponse
+	Err      error
+}
+
+// Stream consulta a IA em streaming. O canal é fechado ao fim da resposta;
+// um erro de leitura chega como último evento.
+func (c *Client) Stream(ctx context.Context, payload QueryRequest) (<-chan StreamEvent, error) {
+	payload.Streaming = true
+	resp, err := c.do(ctx, c.streamClient, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan StreamEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		emit := func(ev StreamEvent) bool {
+			select {
+			case events <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+		var event string
+		var data []string
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line != "" {
+				field, value, _ := strings.Cut(line, ":")
+				value = strings.TrimPrefix(value, " ")
+				switch field {
+				case "event":
+					event = value
+				case "data":
+					data = append(data, value)
+				}
+				continue
+			}
+
+			// linha em branco fecha o evento
+			ev, done := parseEvent(event, strings.Join(data, "\n"))
+			event, data = "", nil
+			if done {
+				return
+			}
+			if ev != nil && !emit(*ev) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			emit(StreamEvent{Err: fmt.Errorf("read chatvolt stream: %w", err)})
+			return
+		}
+		// stream encerrado sem linha em branco final
+		if ev, done := parseEvent(event, strings.Join(data, "\n")); !done && ev != nil {
+			emit(*ev)
+		}
+	}()
+
+	return events, nil
+}
+
+// parseEvent interpreta um evento SSE; done indica o fim do stream.
+func parseEvent(event, data string) (ev *StreamEvent, done bool) {
+	if data == "" {
+		return nil, false
+	}
+	if data == streamDone {
+		return nil, true
+	}
+
+	if event == eventEndpointResponse || strings.HasPrefix(data, "{") {
+		var parsed QueryResponse
+		if err := json.Unmarshal([]byte(data), &parsed); err == nil {
+			return &StreamEvent{Response: &parsed}, false
+		}
+		if event == eventEndpointResponse {
+			return &StreamEvent{Err: fmt.Errorf("decode chatvolt stream response: %s", data)}, false
+		}
+	}
+	return &StreamEvent{Token: data}, false
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type ServerConfig struct {
@@ -18,21 +20,369 @@ type CSAConfig struct {
 	Token     string `json:"token"`
 
 	InsecureSkipVerify bool `json:"insecure_skip_verify"`
+
+	// Channels define envio e formatação por canal (whatsapp, instagram,
+	// messenger, webchat...). O canal vem do campo "channel" do webhook.
+	Channels Channels `json:"channels"`
+}
+
+// DefaultChannel é usado quando o webhook não informa o canal.
+const DefaultChannel = "whatsapp"
+
+// ChannelConfig define como enviar mensagens por um canal da CSA.
+type ChannelConfig struct {
+	// Product e Provider vão no corpo do envio (padrão: nome do canal / "gupshup").
+	Product  string `json:"product"`
+	Provider string `json:"provider"`
+	// SendPath aceita {channel} e {webhook_id}; padrão
+	// "/api/integration/{channel}/{webhook_id}/send".
+	SendPath string `json:"send_path"`
+	// WebhookID da integração do canal (padrão: csa.webhook_id).
+	WebhookID string `json:"webhook_id"`
+
+	// MaxLength por mensagem; 0 usa whatsapp.split.max_length.
+	MaxLength int `json:"max_length"`
+	// Format: "whatsapp" (*negrito*), "markdown" (sem conversão) ou "plain" (sem marcação).
+	Format string `json:"format"`
+	// Interactive indica suporte a botões/listas; sem ele viram opções numeradas.
+	Interactive bool `json:"interactive"`
+}
+
+// Channels indexa a configuração por nome do canal.
+type Channels map[string]ChannelConfig
+
+// Get retorna a configuração do canal; canais não configurados usam o nome
+// como product e texto sem marcação.
+func (c Channels) Get(name string) ChannelConfig {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = DefaultChannel
+	}
+
+	ch, ok := c[name]
+	if !ok {
+		ch = ChannelConfig{Format: "plain"}
+	}
+	if ch.Product == "" {
+		ch.Product = name
+	}
+	return ch
 }
 
 type ChatvoltConfig struct {
-	Token   string `json:"token"`
-	AgentID string `json:"agent_id"`
+	Token     string          `json:"token"`
+	AgentID   string          `json:"agent_id"`
+	Streaming StreamingConfig `json:"streaming"`
+	Callback  CallbackConfig  `json:"callback"`
+}
+
+// CallbackConfig ativa o modo assíncrono: a consulta vai com callbackURL e a
+// resposta chega depois em /chatvolt/callback.
+type CallbackConfig struct {
+	Enabled bool `json:"enabled"`
+	// PublicURL é o endereço do integrador visto pelo Chatvolt (ex.: https://integrador.exemplo.com).
+	PublicURL string `json:"public_url"`
+	// TimeoutSeconds sem resposta aciona OnTimeout.
+	TimeoutSeconds int `json:"timeout_seconds"`
+	// OnTimeout: "retry" repete a consulta de forma síncrona; "apology" envia Message.
+	OnTimeout string `json:"on_timeout"`
+	Message   string `json:"message"`
+}
+
+func (c CallbackConfig) Timeout() time.Duration {
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// StreamingConfig consulta o Chatvolt em streaming (SSE) e envia a resposta em
+// partes, sempre cortando em fim de frase ou parágrafo.
+type StreamingConfig struct {
+	Enabled bool `json:"enabled"`
+	// MinChars é o tamanho acumulado a partir do qual uma parte é enviada.
+	MinChars int `json:"min_chars"`
+	// FlushMS envia o que já estiver completo após esse tempo, mesmo abaixo de MinChars.
+	FlushMS int `json:"flush_ms"`
+}
+
+func (s StreamingConfig) FlushInterval() time.Duration {
+	return time.Duration(s.FlushMS) * time.Millisecond
 }
 
 type IAConfig struct {
 	Chatvolt ChatvoltConfig `json:"chatvolt"`
+	// Routes escolhem outro agente para parte do tráfego; a primeira que
+	// selecionar o contato vence e o restante segue com Chatvolt.AgentID.
+	Routes []RouteConfig `json:"routes"`
+}
+
+// RouteConfig seleciona contatos por allowlist, número de destino ou
+// porcentagem (hash estável do telefone) e define o agente usado para eles.
+type RouteConfig struct {
+	Name    string   `json:"name"`
+	Phones  []string `json:"phones"`
+	To      []string `json:"to"`
+	Percent float64  `json:"percent"`
+
+	AgentID     string   `json:"agent_id"`
+	ModelName   string   `json:"model_name"`
+	Temperature *float64 `json:"temperature"`
+}
+
+// DebounceConfig agrupa rajadas de mensagens do mesmo telefone em uma única consulta.
+// WindowMS zero desabilita o agrupamento.
+type DebounceConfig struct {
+	WindowMS  int `json:"window_ms"`
+	MaxWaitMS int `json:"max_wait_ms"`
+}
+
+func (d DebounceConfig) Window() time.Duration {
+	return time.Duration(d.WindowMS) * time.Millisecond
+}
+
+func (d DebounceConfig) MaxWait() time.Duration {
+	return time.Duration(d.MaxWaitMS) * time.Millisecond
+}
+
+// HandoffConfig controla a transferência do contato para atendimento humano.
+type HandoffConfig struct {
+	// Keywords enviadas pelo cliente que pedem um atendente. Valem só quando
+	// são a mensagem inteira (sem diferenciar caixa, acentos e pontuação final).
+	Keywords []string `json:"keywords"`
+	// Message é enviada ao cliente quando a transferência acontece (vazio = silêncio).
+	Message string `json:"message"`
+	// IdleTimeoutMinutes devolve a sessão para a IA após esse tempo sem mensagens.
+	IdleTimeoutMinutes int `json:"idle_timeout_minutes"`
+}
+
+func (h HandoffConfig) IdleTimeout() time.Duration {
+	return time.Duration(h.IdleTimeoutMinutes) * time.Minute
+}
+
+// DedupConfig controla a supressão de webhooks reentregues (mesmo messageId).
+type DedupConfig struct {
+	WindowMinutes int `json:"window_minutes"`
+	MaxEntries    int `json:"max_entries"`
+	// Path do arquivo de persistência (vazio = somente memória).
+	Path string `json:"path"`
+}
+
+func (d DedupConfig) Window() time.Duration {
+	return time.Duration(d.WindowMinutes) * time.Minute
+}
+
+// WebhookAuthConfig define a verificação das chamadas ao webhook. Cada
+// mecanismo só é exigido quando configurado.
+type WebhookAuthConfig struct {
+	// Secret compartilhado, enviado no header SecretHeader ou na query SecretQuery.
+	Secret       string `json:"secret"`
+	SecretHeader string `json:"secret_header"`
+	SecretQuery  string `json:"secret_query"`
+
+	// HMACSecret habilita a assinatura HMAC-SHA256 de "<timestamp>.<body>".
+	HMACSecret      string `json:"hmac_secret"`
+	SignatureHeader string `json:"signature_header"`
+	TimestampHeader string `json:"timestamp_header"`
+	MaxSkewSeconds  int    `json:"max_skew_seconds"`
+
+	// AllowedIPs aceita IPs ou CIDRs; vazio libera qualquer origem.
+	AllowedIPs []string `json:"allowed_ips"`
+	// TrustForwardedFor usa o X-Forwarded-For (atrás de proxy) para o allowlist.
+	TrustForwardedFor bool `json:"trust_forwarded_for"`
+	// TrustedHops é a quantidade de proxies confiáveis na frente do serviço; o
+	// cliente é o endereço que o mais externo deles acrescentou (padrão 1).
+	TrustedHops int `json:"trusted_hops"`
+}
+
+func (a WebhookAuthConfig) MaxSkew() time.Duration {
+	return time.Duration(a.MaxSkewSeconds) * time.Second
+}
+
+// MediaStrategy define o que fazer com um tipo de mensagem sem texto puro
+// ou com uma opção escolhida em botões/lista.
+type MediaStrategy struct {
+	// Action: "forward" (descrição para a IA), "reply" (resposta fixa),
+	// "handoff" (transfere para humano) ou "ignore".
+	Action string `json:"action"`
+	// Reply é a resposta enviada nas ações "reply" e "handoff".
+	Reply string `json:"reply"`
+}
+
+// SplitConfig controla a quebra de respostas longas em várias mensagens.
+type SplitConfig struct {
+	// MaxLength em caracteres por mensagem (padrão 4096, limite do WhatsApp).
+	MaxLength int `json:"max_length"`
+	// Numbering acrescenta "(1/3)" ao final de cada parte.
+	Numbering bool `json:"numbering"`
+}
+
+// CommandConfig define os gatilhos de um comando embutido (reset, human,
+// help, optout, optin) e a confirmação enviada ao cliente.
+type CommandConfig struct {
+	// Triggers são comparados com a mensagem inteira, sem acento e sem caixa;
+	// lista vazia desabilita o comando.
+	Triggers []string `json:"triggers"`
+	Reply    string   `json:"reply"`
+}
+
+// FallbackConfig define a reação quando a consulta à IA falha.
+type FallbackConfig struct {
+	// Message é enviada ao cliente quando a IA falha (vazio = silêncio).
+	Message string `json:"message"`
+	// Handoff transfere a sessão para atendimento humano junto com a mensagem.
+	Handoff bool `json:"handoff"`
+	// RetryWindowSeconds é o prazo para tentar a consulta de novo em segundo
+	// plano e entregar a resposta atrasada (padrão 120; negativo desabilita).
+	RetryWindowSeconds   int `json:"retry_window_seconds"`
+	RetryIntervalSeconds int `json:"retry_interval_seconds"`
+}
+
+func (f FallbackConfig) RetryWindow() time.Duration {
+	return time.Duration(f.RetryWindowSeconds) * time.Second
+}
+
+func (f FallbackConfig) RetryInterval() time.Duration {
+	return time.Duration(f.RetryIntervalSeconds) * time.Second
+}
+
+// InboundProvidersConfig define os formatos de webhook aceitos.
+type InboundProvidersConfig struct {
+	// Default fixa o formato de /whatsapp/webhook: "auto" (detecta pelo corpo,
+	// padrão), "csa", "gupshup" ou "meta". /whatsapp/webhook/{formato} sempre vale.
+	Default string `json:"default"`
+	// MetaVerifyToken responde ao GET hub.challenge da Cloud API.
+	MetaVerifyToken string `json:"meta_verify_token"`
+	// MetaAppSecret valida o X-Hub-Signature-256 dos webhooks da Cloud API.
+	// Obrigatório com auth.hmac_secret, que não se aplica à rota da Meta.
+	MetaAppSecret string `json:"meta_app_secret"`
+}
+
+// WhatsAppConfig agrega o comportamento do handler de webhooks.
+type WhatsAppConfig struct {
+	// TenantFrom define como separar sessões por número/tenant de destino:
+	// "to" (padrão, com fallback para platformId), "platform" ou "none".
+	TenantFrom string `json:"tenant_from"`
+	// DefaultCountry é o código de país assumido para números nacionais sem o
+	// 0 de longa distância ("55", padrão) ou "none" para exigir o código.
+	DefaultCountry string `json:"default_country"`
+
+	// InboundWorkers e InboundQueueSize dimensionam o processamento assíncrono dos webhooks.
+	InboundWorkers   int `json:"inbound_workers"`
+	InboundQueueSize int `json:"inbound_queue_size"`
+
+	Debounce DebounceConfig `json:"debounce"`
+	Handoff  HandoffConfig  `json:"handoff"`
+	Dedup    DedupConfig    `json:"dedup"`
+
+	Auth      WebhookAuthConfig      `json:"auth"`
+	Providers InboundProvidersConfig `json:"providers"`
+
+	// Media define a estratégia por tipo: image, audio, video, document,
+	// sticker, location, contact.
+	Media map[string]MediaStrategy `json:"media"`
+	// Options define regras locais por id da opção escolhida em botões/listas;
+	// ids sem regra são encaminhados à IA.
+	Options map[string]MediaStrategy `json:"options"`
+
+	Split    SplitConfig    `json:"split"`
+	Fallback FallbackConfig `json:"fallback"`
+
+	// Commands por ação: "reset", "human", "help", "optout" e "optin".
+	Commands map[string]CommandConfig `json:"commands"`
+}
+
+// BusinessHoursConfig define o expediente do atendimento humano e o que
+// fazer fora dele. Sem schedule o atendimento é considerado sempre aberto.
+type BusinessHoursConfig struct {
+	// Timezone IANA, ex.: "America/Sao_Paulo" (padrão).
+	Timezone string `json:"timezone"`
+	// Schedule por dia da semana ("monday".."sunday" ou "seg".."dom") com
+	// intervalos "08:00-12:00".
+	Schedule map[string][]string `json:"schedule"`
+	// Holidays em "2006-01-02" (data única) ou "01-02" (todo ano).
+	Holidays []string `json:"holidays"`
+
+	OutOfHours OutOfHoursConfig `json:"out_of_hours"`
+	// OutOfHoursWindows troca a política em partes do período fechado (ex.:
+	// noites, fins de semana, feriados); vale a primeira que cobrir o instante.
+	OutOfHoursWindows []OutOfHoursWindow `json:"out_of_hours_windows"`
+}
+
+// OutOfHoursWindow é uma política de fora do expediente restrita a dias,
+// horários ou feriados. Action, Message, AgentID e HandoffMessage vazios
+// herdam de out_of_hours; RefuseHandoff vale como configurado na janela.
+type OutOfHoursWindow struct {
+	// Days com os mesmos nomes de schedule; vazio = todos os dias.
+	Days []string `json:"days"`
+	// Hours em intervalos "18:00-22:00"; vazio = o dia todo.
+	Hours []string `json:"hours"`
+	// Holidays restringe a janela aos feriados.
+	Holidays bool `json:"holidays"`
+
+	OutOfHoursConfig
+}
+
+// OutOfHoursConfig é a política aplicada fora do expediente. Nas mensagens,
+// "{next}" é trocado pela próxima abertura (ex.: "segunda-feira às 08:00").
+type OutOfHoursConfig struct {
+	// Action: "ai" (IA continua respondendo, padrão) ou "reply" (somente Message).
+	Action string `json:"action"`
+	// Message é enviada uma vez por sessão em cada período fechado.
+	Message string `json:"message"`
+	// AgentID usa outro agente do Chatvolt fora do expediente.
+	AgentID string `json:"agent_id"`
+	// RefuseHandoff recusa transferências para humano, respondendo HandoffMessage.
+	RefuseHandoff  bool   `json:"refuse_handoff"`
+	HandoffMessage string `json:"handoff_message"`
+}
+
+// SuppressionConfig define a lista de contatos que não recebem mensagens
+// (opt-out e bloqueios).
+type SuppressionConfig struct {
+	// Path do arquivo de persistência (vazio = somente memória).
+	Path string `json:"path"`
+}
+
+// SessionEventsConfig define os destinos dos eventos de sessão além do log.
+type SessionEventsConfig struct {
+	File struct {
+		Path       string `json:"path"`
+		MaxSizeMB  int    `json:"max_size_mb"`
+		MaxBackups int    `json:"max_backups"`
+	} `json:"file"`
+	Webhook struct {
+		URL             string            `json:"url"`
+		Headers         map[string]string `json:"headers"`
+		BatchSize       int               `json:"batch_size"`
+		FlushIntervalMS int               `json:"flush_interval_ms"`
+	} `json:"webhook"`
+	// RingSize é a quantidade de eventos mantidos em memória para a API (0 = 1000).
+	RingSize int `json:"ring_size"`
+}
+
+// TranscriptConfig controla o armazenamento das conversas para auditoria.
+type TranscriptConfig struct {
+	// Path do arquivo JSONL de persistência (vazio = somente memória).
+	Path string `json:"path"`
+	// MaxPerPhone limita as entradas por telefone em memória e no arquivo,
+	// que é compactado na abertura e periodicamente (padrão 2000).
+	MaxPerPhone int `json:"max_per_phone"`
+}
+
+// AdminConfig protege os endpoints administrativos.
+type AdminConfig struct {
+	Token string `json:"token"`
 }
 
 type Config struct {
 	Server ServerConfig `json:"server"`
 	CSA    CSAConfig    `json:"csa"`
 	IA     IAConfig     `json:"ia"`
+	Admin  AdminConfig  `json:"admin"`
+
+	WhatsApp      WhatsAppConfig      `json:"whatsapp"`
+	SessionEvents SessionEventsConfig `json:"session_events"`
+	Transcripts   TranscriptConfig    `json:"transcripts"`
+	BusinessHours BusinessHoursConfig `json:"business_hours"`
+	Suppression   SuppressionConfig   `json:"suppression"`
 }
 
 func Load(path string) (*Config, error) {
@@ -64,5 +414,182 @@ func Load(path string) (*Config, error) {
 		}
 	}
 
+	if cfg.IA.Chatvolt.Streaming.MinChars <= 0 {
+		cfg.IA.Chatvolt.Streaming.MinChars = 300
+	}
+	if cfg.IA.Chatvolt.Streaming.FlushMS <= 0 {
+		cfg.IA.Chatvolt.Streaming.FlushMS = 2000
+	}
+
+	callback := &cfg.IA.Chatvolt.Callback
+	if callback.TimeoutSeconds <= 0 {
+		callback.TimeoutSeconds = 60
+	}
+	if callback.OnTimeout == "" {
+		callback.OnTimeout = "retry"
+	}
+	if callback.Message == "" {
+		callback.Message = "Desculpe a demora! Não consegui concluir sua resposta agora. Pode repetir a pergunta, por favor?"
+	}
+
+	if cfg.WhatsApp.Debounce.WindowMS > 0 && cfg.WhatsApp.Debounce.MaxWaitMS < cfg.WhatsApp.Debounce.WindowMS {
+		cfg.WhatsApp.Debounce.MaxWaitMS = cfg.WhatsApp.Debounce.WindowMS * 4
+	}
+
+	if cfg.WhatsApp.TenantFrom == "" {
+		cfg.WhatsApp.TenantFrom = "to"
+	}
+	if cfg.WhatsApp.DefaultCountry == "" {
+		cfg.WhatsApp.DefaultCountry = "55"
+	}
+
+	if cfg.WhatsApp.Dedup.WindowMinutes == 0 {
+		cfg.WhatsApp.Dedup.WindowMinutes = 60
+	}
+
+	if cfg.WhatsApp.Handoff.Keywords == nil {
+		cfg.WhatsApp.Handoff.Keywords = []string{
+			"atendente",
+			"falar com atendente",
+			"falar com um atendente",
+			"quero falar com um atendente",
+			"atendimento humano",
+		}
+	}
+	if cfg.WhatsApp.Handoff.Message == "" {
+		cfg.WhatsApp.Handoff.Message = "Certo! Vou transferir você para um de nossos atendentes, aguarde um momento."
+	}
+	if cfg.WhatsApp.Handoff.IdleTimeoutMinutes == 0 {
+		cfg.WhatsApp.Handoff.IdleTimeoutMinutes = 30
+	}
+
+	if cfg.Transcripts.MaxPerPhone == 0 {
+		cfg.Transcripts.MaxPerPhone = 2000
+	}
+
+	defaultChannels := Channels{
+		"whatsapp":  {Product: "whatsapp", Provider: "gupshup", Format: "whatsapp", Interactive: true},
+		"instagram": {Product: "instagram", MaxLength: 1000, Format: "plain"},
+		"messenger": {Product: "messenger", MaxLength: 2000, Format: "plain"},
+		"webchat":   {Product: "webchat", Format: "markdown"},
+	}
+	if cfg.CSA.Channels == nil {
+		cfg.CSA.Channels = make(Channels)
+	}
+	for name, channel := range defaultChannels {
+		if _, ok := cfg.CSA.Channels[name]; !ok {
+			cfg.CSA.Channels[name] = channel
+		}
+	}
+
+	defaultMedia := map[string]MediaStrategy{
+		"image":    {Action: "forward"},
+		"document": {Action: "forward"},
+		"location": {Action: "forward"},
+		"contact":  {Action: "forward"},
+		"audio":    {Action: "reply", Reply: "Ainda não consigo ouvir áudios por aqui. Pode me enviar sua mensagem por escrito?"},
+		"video":    {Action: "reply", Reply: "Ainda não consigo assistir vídeos por aqui. Pode me descrever por escrito?"},
+		"sticker":  {Action: "ignore"},
+	}
+	if cfg.WhatsApp.Media == nil {
+		cfg.WhatsApp.Media = make(map[string]MediaStrategy)
+	}
+	for kind, strategy := range defaultMedia {
+		if _, ok := cfg.WhatsApp.Media[kind]; !ok {
+			cfg.WhatsApp.Media[kind] = strategy
+		}
+	}
+
+	defaultCommands := map[string]CommandConfig{
+		"reset":  {Triggers: []string{"/reset", "/reiniciar", "/recomecar"}, Reply: "Pronto! Começamos uma nova conversa. Como posso ajudar?"},
+		"human":  {Triggers: []string{"/humano", "/atendente"}, Reply: cfg.WhatsApp.Handoff.Message},
+		"help":   {Triggers: []string{"/ajuda", "/help", "/comandos"}},
+		"optout": {Triggers: []string{"/sair", "sair", "stop", "parar"}, Reply: "Tudo bem, você não receberá mais mensagens nossas. Para voltar, envie VOLTAR."},
+		"optin":  {Triggers: []string{"/voltar", "voltar", "start"}, Reply: "Que bom ter você de volta! Como posso ajudar?"},
+	}
+	if cfg.WhatsApp.Commands == nil {
+		cfg.WhatsApp.Commands = make(map[string]CommandConfig)
+	}
+	for action, command := range defaultCommands {
+		if _, ok := cfg.WhatsApp.Commands[action]; !ok {
+			cfg.WhatsApp.Commands[action] = command
+		}
+	}
+
+	if cfg.WhatsApp.Split.MaxLength <= 0 {
+		cfg.WhatsApp.Split.MaxLength = 4096
+	}
+
+	if cfg.WhatsApp.Fallback.Message == "" {
+		cfg.WhatsApp.Fallback.Message = "Estamos com instabilidade no momento. Já vamos te responder, ou um atendente entrará em contato em breve."
+	}
+	if cfg.WhatsApp.Fallback.RetryWindowSeconds == 0 {
+		cfg.WhatsApp.Fallback.RetryWindowSeconds = 120
+	}
+	if cfg.WhatsApp.Fallback.RetryIntervalSeconds <= 0 {
+		cfg.WhatsApp.Fallback.RetryIntervalSeconds = 15
+	}
+
+	if cfg.BusinessHours.Timezone == "" {
+		cfg.BusinessHours.Timezone = "America/Sao_Paulo"
+	}
+	if cfg.BusinessHours.OutOfHours.Action == "" {
+		cfg.BusinessHours.OutOfHours.Action = "ai"
+	}
+	if cfg.BusinessHours.OutOfHours.HandoffMessage == "" {
+		cfg.BusinessHours.OutOfHours.HandoffMessage = "Nossos atendentes não estão disponíveis agora. Voltamos {next}; até lá, posso continuar ajudando por aqui."
+	}
+	for i := range cfg.BusinessHours.OutOfHoursWindows {
+		w, base := &cfg.BusinessHours.OutOfHoursWindows[i], cfg.BusinessHours.OutOfHours
+		if w.Action == "" {
+			w.Action = base.Action
+		}
+		if w.Message == "" {
+			w.Message = base.Message
+		}
+		if w.AgentID == "" {
+			w.AgentID = base.AgentID
+		}
+		if w.HandoffMessage == "" {
+			w.HandoffMessage = base.HandoffMessage
+		}
+	}
+
+	auth := &cfg.WhatsApp.Auth
+	if auth.SecretHeader == "" {
+		auth.SecretHeader = "X-Webhook-Token"
+	}
+	if auth.SecretQuery == "" {
+		auth.SecretQuery = "token"
+	}
+	if auth.SignatureHeader == "" {
+		auth.SignatureHeader = "X-Signature"
+	}
+	if auth.TimestampHeader == "" {
+		auth.TimestampHeader = "X-Timestamp"
+	}
+	if auth.TrustedHops <= 0 {
+		auth.TrustedHops = 1
+	}
+	if auth.MaxSkewSeconds == 0 {
+		auth.MaxSkewSeconds = 300
+	}
+	if secret, ok := os.LookupEnv("WEBHOOK_SECRET"); ok {
+		auth.Secret = secret
+	}
+	if secret, ok := os.LookupEnv("WEBHOOK_HMAC_SECRET"); ok {
+		auth.HMACSecret = secret
+	}
+	if token, ok := os.LookupEnv("META_VERIFY_TOKEN"); ok {
+		cfg.WhatsApp.Providers.MetaVerifyToken = token
+	}
+	if secret, ok := os.LookupEnv("META_APP_SECRET"); ok {
+		cfg.WhatsApp.Providers.MetaAppSecret = secret
+	}
+
+	if token, ok := os.LookupEnv("ADMIN_TOKEN"); ok {
+		cfg.Admin.Token = token
+	}
+
 	return &cfg, nil
 }
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"whatsapp-ia-integrator/internal/config"
@@ -14,10 +15,10 @@ import (
 
 const (
 	defaultCSAURL     = "https://csa.wonit.net.br"
-	defaultProduct    = "whatsapp"
 	defaultProvider   = "gupshup"
 	defaultInstanceID = "3f9e541b-90b1-4052-abef-f1835a43e470" // AJUSTA para tua instância
 	defaultSenderName = "Wonit Tecnologia"
+	defaultSendPath   = "/api/integration/{channel}/{webhook_id}/send"
 )
 
 type Client struct {
@@ -38,20 +39,55 @@ func NewClient(cfg config.CSAConfig) *Client {
 }
 
 type SendMessageRequest struct {
-	Address     string `json:"address,omitempty"`
-	Caption     string `json:"caption,omitempty"`
-	Destination string `json:"destination"`
-	Filename    string `json:"filename,omitempty"`
-	InstanceID  string `json:"instanceId"`
-	Latitude    string `json:"latitude,omitempty"`
-	Longitude   string `json:"longitude,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Preview     bool   `json:"preview"`
-	Product     string `json:"product"`
-	Provider    string `json:"provider"`
-	Text        string `json:"text,omitempty"`
-	Type        string `json:"type"` // "text", "image", "document", etc.
-	URL         string `json:"url,omitempty"`
+	// Channel escolhe a configuração de envio (csa.channels); não vai no corpo.
+	Channel string `json:"-"`
+
+	Address     string       `json:"address,omitempty"`
+	Caption     string       `json:"caption,omitempty"`
+	Destination string       `json:"destination"`
+	Filename    string       `json:"filename,omitempty"`
+	InstanceID  string       `json:"instanceId"`
+	Interactive *Interactive `json:"interactive,omitempty"`
+	Latitude    string       `json:"latitude,omitempty"`
+	Longitude   string       `json:"longitude,omitempty"`
+	Name        string       `json:"name,omitempty"`
+	Preview     bool         `json:"preview"`
+	Product     string       `json:"product"`
+	Provider    string       `json:"provider"`
+	Text        string       `json:"text,omitempty"`
+	Type        string       `json:"type"` // "text", "image", "document", "interactive", etc.
+	URL         string       `json:"url,omitempty"`
+}
+
+// Interactive é o conteúdo de uma mensagem com botões de resposta rápida
+// (Type "button", até 3) ou menu de lista (Type "list", até 10 itens).
+type Interactive struct {
+	Type   string `json:"type"`
+	Header string `json:"header,omitempty"`
+	Body   string `json:"body"`
+	Footer string `json:"footer,omitempty"`
+
+	Buttons []Button `json:"buttons,omitempty"`
+
+	// ButtonText é o rótulo do botão que abre a lista.
+	ButtonText string        `json:"buttonText,omitempty"`
+	Sections   []ListSection `json:"sections,omitempty"`
+}
+
+type Button struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type ListSection struct {
+	Title string    `json:"title,omitempty"`
+	Rows  []ListRow `json:"rows"`
+}
+
+type ListRow struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
 }
 
 type SendMessageResponse struct {
@@ -64,8 +100,12 @@ func (c *Client) SendMessage(ctx context.Context, payload *SendMessageRequest) (
 	if payload.InstanceID == "" {
 		payload.InstanceID = defaultInstanceID
 	}
+	channel := c.cfg.Channels.Get(payload.Channel)
 	if payload.Product == "" {
-		payload.Product = defaultProduct
+		payload.Product = channel.Product
+	}
+	if payload.Provider == "" {
+		payload.Provider = channel.Provider
 	}
 	if payload.Provider == "" {
 		payload.Provider = defaultProvider
@@ -79,12 +119,7 @@ func (c *Client) SendMessage(ctx context.Context, payload *SendMessageRequest) (
 		return nil, fmt.Errorf("marshal csa payload: %w", err)
 	}
 
-	baseURL := c.cfg.URL
-	if baseURL == "" {
-		baseURL = defaultCSAURL
-	}
-
-	url := fmt.Sprintf("%s/api/integration/whatsapp/%s/send", baseURL, c.cfg.WebhookID)
+	url := c.sendURL(payload.Channel, channel)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
@@ -113,3 +148,27 @@ func (c *Client) SendMessage(ctx context.Context, payload *SendMessageRequest) (
 
 	return &data, nil
 }
+
+// sendURL monta o endpoint de envio do canal.
+func (c *Client) sendURL(name string, channel config.ChannelConfig) string {
+	baseURL := c.cfg.URL
+	if baseURL == "" {
+		baseURL = defaultCSAURL
+	}
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = config.DefaultChannel
+	}
+	webhookID := channel.WebhookID
+	if webhookID == "" {
+		webhookID = c.cfg.WebhookID
+	}
+	path := channel.SendPath
+	if path == "" {
+		path = defaultSendPath
+	}
+
+	path = strings.NewReplacer("{channel}", name, "{webhook_id}", webhookID).Replace(path)
+	return strings.TrimRight(baseURL, "/") + "/" + strings.TrimLeft(path, "/")
+}
@@ -0,0 +1,246 @@
+package dedup
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store lembra ids processados recentemente, limitado por janela de tempo e
+// quantidade máxima de entradas. Com path preenchido, sobrevive a reinícios.
+type Store struct {
+	window     time.Duration
+	maxEntries int
+
+	mu    sync.Mutex
+	seen  map[string]time.Time
+	order []entry // ordem de chegada, para expirar pela frente
+	path  string
+	file  *os.File
+	// written conta as linhas gravadas desde a última compactação.
+	written int
+}
+
+type entry struct {
+	ID string    `json:"id"`
+	At time.Time `json:"at"`
+	// Forget desfaz o registro do id (ver Store.Forget).
+	Forget bool `json:"forget,omitempty"`
+}
+
+// NewStore cria o store. window <= 0 usa 1h; maxEntries <= 0 usa 10000.
+func NewStore(window time.Duration, maxEntries int, path string) (*Store, error) {
+	if window <= 0 {
+		window = time.Hour
+	}
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+
+	s := &Store{
+		window:     window,
+		maxEntries: maxEntries,
+		seen:       make(map[string]time.Time),
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	s.path = path
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Seen verifica os ids de forma atômica: se algum já foi visto dentro da janela
+// retorna true; caso contrário registra todos e retorna false.
+func (s *Store) Seen(ids ...string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	s.evict(now)
+
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		if _, ok := s.seen[id]; ok {
+			return true
+		}
+	}
+
+	for _, id := range ids {
+		if id == "" {
+			continue
+		}
+		s.add(entry{ID: id, At: now})
+		s.persist(entry{ID: id, At: now})
+	}
+	return false
+}
+
+// Forget remove ids registrados (ex.: a mensagem não pôde ser enfileirada e
+// a reentrega precisa ser processada). A remoção também vai para o arquivo.
+func (s *Store) Forget(ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	for _, id := range ids {
+		if _, ok := s.seen[id]; !ok {
+			continue
+		}
+		delete(s.seen, id)
+		s.persist(entry{ID: id, At: now, Forget: true})
+	}
+}
+
+// Close fecha o arquivo de persistência.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *Store) add(e entry) {
+	s.seen[e.ID] = e.At
+	s.order = append(s.order, e)
+
+	if over := len(s.order) - s.maxEntries; over > 0 {
+		s.drop(over)
+	}
+}
+
+// evict descarta entradas fora da janela.
+func (s *Store) evict(now time.Time) {
+	cutoff := now.Add(-s.window)
+	n := 0
+	for n < len(s.order) && !s.order[n].At.After(cutoff) {
+		n++
+	}
+	if n > 0 {
+		s.drop(n)
+	}
+}
+
+// drop remove as n entradas mais antigas.
+func (s *Store) drop(n int) {
+	for _, old := range s.order[:n] {
+		if at, ok := s.seen[old.ID]; ok && at.Equal(old.At) {
+			delete(s.seen, old.ID)
+		}
+	}
+	s.order = s.order[n:]
+}
+
+func (s *Store) persist(e entry) {
+	if s.file == nil {
+		return
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		log.Printf("[dedup] erro gravando id %s: %v", e.ID, err)
+	}
+
+	// o arquivo só tem append: compacta quando passa do dobro do limite em memória
+	s.written++
+	if s.written > 2*s.maxEntries {
+		s.evict(time.Now().UTC())
+		if err := s.compact(); err != nil {
+			log.Printf("[dedup] erro compactando arquivo: %v", err)
+		}
+	}
+}
+
+func (s *Store) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open dedup file: %w", err)
+	}
+	defer f.Close()
+
+	cutoff := time.Now().UTC().Add(-s.window)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil || e.ID == "" {
+			continue
+		}
+		if e.Forget {
+			delete(s.seen, e.ID)
+			continue
+		}
+		if e.At.After(cutoff) {
+			s.add(e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read dedup file: %w", err)
+	}
+	return nil
+}
+
+// compact reescreve o arquivo apenas com as entradas vivas e o mantém aberto para append.
+func (s *Store) compact() error {
+	path := s.path
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create dedup file: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	for _, e := range s.order {
+		if at, ok := s.seen[e.ID]; !ok || !at.Equal(e.At) {
+			continue
+		}
+		line, _ := json.Marshal(e)
+		_, _ = w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write dedup file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close dedup file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename dedup file: %w", err)
+	}
+	// o descritor antigo aponta para o arquivo substituído
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+
+	s.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open dedup file: %w", err)
+	}
+	s.written = 0
+	return nil
+}
@@ -0,0 +1,78 @@
+package dedup
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		n++
+	}
+	return n
+}
+
+func TestForgetSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.jsonl")
+	s, err := NewStore(time.Hour, 100, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Seen("a", "b") {
+		t.Fatal("ids novos marcados como vistos")
+	}
+	s.Seen("c")
+	s.Forget("a", "b")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewStore(time.Hour, 100, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if s.Seen("a") {
+		t.Error("id esquecido voltou como visto após reinício")
+	}
+	if !s.Seen("c") {
+		t.Error("id registrado perdido após reinício")
+	}
+}
+
+func TestFileIsCompacted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dedup.jsonl")
+	s, err := NewStore(time.Hour, 10, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 500; i++ {
+		s.Seen(fmt.Sprintf("id-%d", i))
+	}
+
+	if n := countLines(t, path); n > 3*10 {
+		t.Errorf("arquivo com %d linhas, esperado no máximo %d", n, 3*10)
+	}
+	if !s.Seen("id-499") {
+		t.Error("id recente perdido na compactação")
+	}
+	if s.Seen("id-0") {
+		t.Error("id antigo deveria ter saído pelo limite de entradas")
+	}
+}
@@ -0,0 +1,257 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MessageKind é o tipo normalizado de uma mensagem recebida.
+type MessageKind string
+
+const (
+	KindText     MessageKind = "text"
+	KindImage    MessageKind = "image"
+	KindAudio    MessageKind = "audio"
+	KindVideo    MessageKind = "video"
+	KindDocument MessageKind = "document"
+	KindSticker  MessageKind = "sticker"
+	KindLocation MessageKind = "location"
+	KindContact  MessageKind = "contact"
+	// KindInteractive é a resposta a botões ou a um menu de lista.
+	KindInteractive MessageKind = "interactive"
+	KindUnknown     MessageKind = "unknown"
+)
+
+// SharedContact é um contato (vCard) compartilhado pelo cliente.
+type SharedContact struct {
+	Name   string   `json:"name,omitempty"`
+	Phones []string `json:"phones,omitempty"`
+}
+
+// InteractiveReply é a opção escolhida pelo cliente em botões ou lista.
+type InteractiveReply struct {
+	// Type: "button_reply" ou "list_reply".
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// InboundMessage é a mensagem recebida já normalizada, independente do formato do rawPayload.
+type InboundMessage struct {
+	Kind MessageKind `json:"kind"`
+	// Text traz o corpo do texto ou a legenda da mídia.
+	Text string `json:"text,omitempty"`
+
+	MediaURL string `json:"mediaUrl,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	// Voice indica áudio gravado no app (nota de voz).
+	Voice bool `json:"voice,omitempty"`
+
+	Latitude     float64 `json:"latitude,omitempty"`
+	Longitude    float64 `json:"longitude,omitempty"`
+	LocationName string  `json:"locationName,omitempty"`
+	Address      string  `json:"address,omitempty"`
+
+	Contacts []SharedContact `json:"contacts,omitempty"`
+
+	Reply *InteractiveReply `json:"reply,omitempty"`
+}
+
+// Message normaliza o payload. Entende o formato da Cloud API (objeto por tipo,
+// ex.: {"type":"image","image":{...}}), o formato Gupshup ({"type":"image","payload":{...}})
+// e campos soltos no rawPayload (url, caption, latitude...).
+func (i InboundWebhook) Message() InboundMessage {
+	kind := normalizeKind(i.Type)
+	if raw, ok := i.RawPayload["type"].(string); ok && raw != "" {
+		kind = normalizeKind(raw)
+	}
+
+	msg := InboundMessage{Kind: kind}
+
+	// objeto com os dados do tipo: raw[type] (Cloud API), raw["payload"] (Gupshup) ou o próprio raw
+	body := i.RawPayload
+	if typed, ok := i.RawPayload[rawTypeKey(i.RawPayload, kind)].(map[string]any); ok {
+		body = typed
+	} else if payload, ok := i.RawPayload["payload"].(map[string]any); ok {
+		body = payload
+	}
+
+	switch kind {
+	case KindImage, KindAudio, KindVideo, KindDocument, KindSticker:
+		msg.MediaURL = firstString(body, "url", "link", "mediaUrl")
+		msg.MimeType = firstString(body, "mime_type", "mimeType", "contentType")
+		msg.Filename = firstString(body, "filename", "name")
+		msg.Text = firstString(body, "caption")
+		msg.Voice, _ = body["voice"].(bool)
+	case KindLocation:
+		msg.Latitude = number(body["latitude"])
+		msg.Longitude = number(body["longitude"])
+		msg.LocationName = firstString(body, "name")
+		msg.Address = firstString(body, "address")
+	case KindContact:
+		msg.Contacts = parseContacts(i.RawPayload, body)
+	case KindInteractive:
+		msg.Reply = parseInteractiveReply(i.RawPayload)
+		if msg.Reply == nil {
+			kind = KindUnknown
+			msg.Kind = KindUnknown
+		} else {
+			msg.Text = msg.Reply.Title
+		}
+	}
+
+	if msg.Text == "" {
+		msg.Text = strings.TrimSpace(i.MessageText)
+	}
+	if msg.Text == "" {
+		msg.Text = strings.TrimSpace(i.TextFromRaw())
+	}
+
+	if kind == KindUnknown && msg.Text != "" {
+		msg.Kind = KindText
+	}
+	return msg
+}
+
+func normalizeKind(raw string) MessageKind {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "text", "":
+		return KindText
+	case "image":
+		return KindImage
+	case "audio", "voice", "ptt":
+		return KindAudio
+	case "video":
+		return KindVideo
+	case "document", "file":
+		return KindDocument
+	case "sticker":
+		return KindSticker
+	case "location":
+		return KindLocation
+	case "contact", "contacts", "vcard":
+		return KindContact
+	case "interactive", "button", "button_reply", "list_reply", "quick_reply":
+		return KindInteractive
+	}
+	return KindUnknown
+}
+
+// rawTypeKey retorna a chave do objeto do tipo na Cloud API.
+func rawTypeKey(raw map[string]any, kind MessageKind) string {
+	switch kind {
+	case KindContact:
+		return "contact"
+	case KindDocument:
+		if _, ok := raw["file"]; ok {
+			return "file"
+		}
+	}
+	return string(kind)
+}
+
+// parseInteractiveReply entende a Cloud API (interactive.button_reply/list_reply
+// e button.payload de templates) e o Gupshup (type button_reply/list_reply/quick_reply
+// com payload).
+func parseInteractiveReply(raw map[string]any) *InteractiveReply {
+	if obj, ok := raw["interactive"].(map[string]any); ok {
+		for _, t := range []string{"button_reply", "list_reply"} {
+			if r, ok := obj[t].(map[string]any); ok {
+				return newInteractiveReply(t, firstString(r, "id"), firstString(r, "title"), firstString(r, "description"))
+			}
+		}
+	}
+
+	if b, ok := raw["button"].(map[string]any); ok {
+		return newInteractiveReply("button_reply", firstString(b, "payload", "id"), firstString(b, "text", "title"), "")
+	}
+
+	if p, ok := raw["payload"].(map[string]any); ok {
+		t := "button_reply"
+		if rawType, _ := raw["type"].(string); strings.Contains(strings.ToLower(rawType), "list") {
+			t = "list_reply"
+		}
+		return newInteractiveReply(t, firstString(p, "id", "postbackText"), firstString(p, "title", "text", "reply"), firstString(p, "description"))
+	}
+	return nil
+}
+
+func newInteractiveReply(t, id, title, description string) *InteractiveReply {
+	if id == "" {
+		id = title
+	}
+	if id == "" {
+		return nil
+	}
+	return &InteractiveReply{Type: t, ID: id, Title: title, Description: description}
+}
+
+func parseContacts(raw, body map[string]any) []SharedContact {
+	list, _ := raw["contacts"].([]any)
+	if list == nil {
+		if contacts, ok := body["contacts"].([]any); ok {
+			list = contacts
+		} else {
+			list = []any{body}
+		}
+	}
+
+	out := make([]SharedContact, 0, len(list))
+	for _, item := range list {
+		c, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		var contact SharedContact
+		switch name := c["name"].(type) {
+		case string:
+			contact.Name = name
+		case map[string]any:
+			contact.Name = firstString(name, "formatted_name", "first_name")
+		}
+
+		if phones, ok := c["phones"].([]any); ok {
+			for _, p := range phones {
+				switch pv := p.(type) {
+				case string:
+					contact.Phones = append(contact.Phones, pv)
+				case map[string]any:
+					if phone := firstString(pv, "phone", "wa_id"); phone != "" {
+						contact.Phones = append(contact.Phones, phone)
+					}
+				}
+			}
+		}
+		if phone := firstString(c, "phone"); phone != "" {
+			contact.Phones = append(contact.Phones, phone)
+		}
+
+		if contact.Name != "" || len(contact.Phones) > 0 {
+			out = append(out, contact)
+		}
+	}
+	return out
+}
+
+func firstString(m map[string]any, keys ...string) string {
+	for _, k := range keys {
+		if v, ok := m[k].(string); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+func number(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f
+	}
+	return 0
+}
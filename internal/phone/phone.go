@@ -0,0 +1,115 @@
+package phone
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// BrazilCode é o código de país do Brasil, assumido para números nacionais.
+const BrazilCode = "55"
+
+// defaultCountry é o país dos números sem código de país e sem o 0 de longa
+// distância (Brasil, por padrão); vazio trata esses dígitos como internacionais.
+var defaultCountry atomic.Value
+
+func init() {
+	defaultCountry.Store(BrazilCode)
+}
+
+// SetDefaultCountry define o país assumido para números nacionais sem o 0
+// ("55" ou "none"; só a numeração brasileira é reconhecida).
+func SetDefaultCountry(code string) {
+	defaultCountry.Store(Digits(code))
+}
+
+// Normalize converte o telefone para E.164 ("+5511988887777"). Aceita
+// pontuação, espaços, prefixo internacional "00" e JIDs do WhatsApp
+// ("5511...@s.whatsapp.net"). Dígitos sem "+" já trazem o código de país, como
+// a CSA e o WhatsApp enviam; são tratados como brasileiros (DDD + número) só
+// com o 0 de longa distância ou, com o país padrão "55", quando têm o formato
+// nacional e não podem ser um número NANP (+1). Celulares brasileiros
+// sem o nono dígito recebem o 9. Retorna false quando não parece um telefone.
+func Normalize(raw string) (string, bool) {
+	s := strings.TrimSpace(raw)
+	if at := strings.IndexByte(s, '@'); at >= 0 {
+		s = s[:at]
+	}
+
+	international := strings.HasPrefix(s, "+")
+	d := Digits(s)
+	if !international && strings.HasPrefix(d, "00") {
+		d = d[2:]
+		international = true
+	}
+
+	if !international {
+		if national, trunk := strings.CutPrefix(d, "0"); trunk && (len(national) == 10 || len(national) == 11) && validDDD(national[:2]) {
+			d = BrazilCode + national
+		} else if code, _ := defaultCountry.Load().(string); code == BrazilCode && brazilianNational(d) && !nanp(d) {
+			d = BrazilCode + d
+		}
+	}
+
+	if strings.HasPrefix(d, BrazilCode) && (len(d) == 12 || len(d) == 13) {
+		d = brazil(d)
+	}
+
+	if len(d) < 8 || len(d) > 15 {
+		return "", false
+	}
+	return "+" + d, true
+}
+
+// Canonical retorna o E.164 do telefone ou, se não for um número reconhecível
+// (ex.: id de webchat), o valor original sem espaços nas pontas.
+func Canonical(raw string) string {
+	if n, ok := Normalize(raw); ok {
+		return n
+	}
+	return strings.TrimSpace(raw)
+}
+
+// Digits mantém apenas os dígitos.
+func Digits(raw string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, raw)
+}
+
+// brazil aplica a regra do nono dígito: celulares (8 dígitos iniciando em 6-9)
+// passam a ter 9 dígitos. Fixos (iniciando em 2-5) ficam como estão.
+func brazil(d string) string {
+	ddd, number := d[2:4], d[4:]
+	if !validDDD(ddd) {
+		return d
+	}
+	if len(number) == 8 && number[0] >= '6' {
+		number = "9" + number
+	}
+	return BrazilCode + ddd + number
+}
+
+// brazilianNational reconhece DDD + número sem código de país: celular com 9
+// dígitos (iniciando em 9) ou fixo/celular antigo com 8.
+func brazilianNational(d string) bool {
+	switch {
+	case len(d) == 11:
+		return validDDD(d[:2]) && d[2] == '9'
+	case len(d) == 10:
+		return validDDD(d[:2]) && d[2] >= '2'
+	}
+	return false
+}
+
+// nanp reconhece um número +1 completo: 1 + NXX (área) + NXX + XXXX.
+func nanp(d string) bool {
+	return len(d) == 11 && d[0] == '1' && d[1] >= '2' && d[4] >= '2'
+}
+
+// validDDD aceita os códigos de área brasileiros (11 a 99, sem zero).
+func validDDD(ddd string) bool {
+	return len(ddd) == 2 && ddd[0] >= '1' && ddd[0] <= '9' && ddd[1] >= '1' && ddd[1] <= '9'
+}
@@ -0,0 +1,58 @@
+package phone
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{"+55 (11) 98888-7777", "+5511988887777", true},
+		{"5511988887777", "+5511988887777", true},
+		{"551188887777", "+5511988887777", true},
+		{"551133334444", "+551133334444", true},
+		{"5511988887777@s.whatsapp.net", "+5511988887777", true},
+		{"0055 11 98888-7777", "+5511988887777", true},
+		{"011988887777", "+5511988887777", true},
+		{"01188887777", "+5511988887777", true},
+		{"11988887777", "+5511988887777", true},
+		{"(11) 3333-4444", "+551133334444", true},
+		{"12125550123", "+12125550123", true},
+		{"+1 212 555 0123", "+12125550123", true},
+		{"447911123456", "+447911123456", true},
+		{"webchat-abc", "", false},
+		{"1234", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Normalize(tt.raw)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Normalize(%q) = %q, %v; want %q, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestNormalizeWithoutDefaultCountry(t *testing.T) {
+	SetDefaultCountry("none")
+	defer SetDefaultCountry(BrazilCode)
+
+	tests := map[string]string{
+		"11988887777":  "+11988887777",
+		"011988887777": "+5511988887777",
+		"12125550123":  "+12125550123",
+	}
+	for raw, want := range tests {
+		if got, _ := Normalize(raw); got != want {
+			t.Errorf("Normalize(%q) = %q; want %q", raw, got, want)
+		}
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	if got := Canonical(" webchat-abc "); got != "webchat-abc" {
+		t.Errorf("Canonical = %q", got)
+	}
+	if got := Canonical("5511988887777"); got != "+5511988887777" {
+		t.Errorf("Canonical = %q", got)
+	}
+}
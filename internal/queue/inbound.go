@@ -0,0 +1,307 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	goqueue "github.com/gothout/goqueue"
+
+	"whatsapp-ia-integrator/internal/model"
+)
+
+// InboundStatus representa o estágio de processamento de um webhook recebido.
+type InboundStatus string
+
+const (
+	InboundStatusQueued     InboundStatus = "queued"
+	InboundStatusProcessing InboundStatus = "processing"
+	InboundStatusDebounced  InboundStatus = "debounced"
+	InboundStatusDone       InboundStatus = "done"
+	InboundStatusIgnored    InboundStatus = "ignored"
+	InboundStatusFailed     InboundStatus = "failed"
+)
+
+var errQueueFull = errors.New("fila de entrada cheia")
+
+// InboundInfo agrega o rastreamento de uma mensagem recebida.
+type InboundInfo struct {
+	MessageID  string        `json:"messageId"`
+	Phone      string        `json:"phone,omitempty"`
+	Status     InboundStatus `json:"status"`
+	Error      string        `json:"error,omitempty"`
+	ReceivedAt time.Time     `json:"receivedAt"`
+	UpdatedAt  time.Time     `json:"updatedAt"`
+}
+
+// InboundTracker mantém o status de processamento por messageId de entrada,
+// limitado por tempo de retenção e quantidade máxima de entradas.
+type InboundTracker struct {
+	retention  time.Duration
+	maxEntries int
+
+	mu    sync.RWMutex
+	items map[string]InboundInfo
+	order []string // ordem de chegada, para expirar pela frente
+}
+
+// NewInboundTracker cria o tracker. retention <= 0 usa 24h; maxEntries <= 0 usa 10000.
+func NewInboundTracker(retention time.Duration, maxEntries int) *InboundTracker {
+	if retention <= 0 {
+		retention = 24 * time.Hour
+	}
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &InboundTracker{
+		retention:  retention,
+		maxEntries: maxEntries,
+		items:      make(map[string]InboundInfo),
+	}
+}
+
+// Set cria ou atualiza o status de uma mensagem recebida.
+func (t *InboundTracker) Set(messageID, phone string, status InboundStatus, err error) {
+	if messageID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now().UTC()
+	info, ok := t.items[messageID]
+	if !ok {
+		t.evict(now)
+		info.MessageID = messageID
+		info.ReceivedAt = now
+		t.order = append(t.order, messageID)
+	}
+	if info.Phone == "" {
+		info.Phone = phone
+	}
+	info.Status = status
+	info.Error = ""
+	if err != nil {
+		info.Error = err.Error()
+	}
+	info.UpdatedAt = now
+
+	t.items[messageID] = info
+}
+
+// evict descarta as entradas fora da retenção e o excesso sobre maxEntries,
+// sempre as mais antigas. Chamado com o lock.
+func (t *InboundTracker) evict(now time.Time) {
+	drop := 0
+	for drop < len(t.order) {
+		over := len(t.order)-drop >= t.maxEntries
+		if !over && now.Sub(t.items[t.order[drop]].ReceivedAt) < t.retention {
+			break
+		}
+		delete(t.items, t.order[drop])
+		drop++
+	}
+	if drop > 0 {
+		t.order = append([]string(nil), t.order[drop:]...)
+	}
+}
+
+// Get retorna o status de uma mensagem recebida.
+func (t *InboundTracker) Get(messageID string) (InboundInfo, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	info, ok := t.items[messageID]
+	return info, ok
+}
+
+// InboundProcessor processa um webhook e retorna o status final (ou intermediário,
+// como InboundStatusDebounced, quando outra etapa concluirá o processamento).
+type InboundProcessor func(ctx context.Context, payload model.InboundWebhook) (InboundStatus, error)
+
+// InboundJob é um webhook aceito aguardando processamento.
+type InboundJob struct {
+	// Key agrupa mensagens que precisam ser processadas em ordem (sessão).
+	Key       string
+	MessageID string
+	Phone     string
+	Payload   model.InboundWebhook
+}
+
+// InboundQueue desacopla o recebimento do webhook do processamento (IA), com workers próprios.
+// Cada Key é atendida por um worker de cada vez: jobs que chegam para uma chave
+// ocupada ficam estacionados e são processados em ordem pelo mesmo worker, sem
+// prender os demais. A execução passa pela mailbox para também respeitar as
+// tarefas da sessão vindas de fora da fila (debounce, novas tentativas).
+type InboundQueue struct {
+	workers  int
+	capacity int
+	process  InboundProcessor
+	tracker  *InboundTracker
+	mailbox  *Mailbox
+
+	queue      *goqueue.Queue[InboundJob]
+	dispatchMu sync.Mutex
+	// busy marca as chaves com worker ativo; parked guarda, em ordem, os jobs
+	// que chegaram para elas nesse meio tempo.
+	busy    map[string]bool
+	parked  map[string][]InboundJob
+	parkedN int
+
+	notify   chan struct{}
+	wg       sync.WaitGroup
+	shutdown chan struct{}
+}
+
+// NewInboundQueue cria a fila de entrada. capacity <= 0 usa 1000.
+func NewInboundQueue(workers, capacity int, tracker *InboundTracker, mailbox *Mailbox, process InboundProcessor) *InboundQueue {
+	if workers <= 0 {
+		workers = 4
+	}
+	if capacity <= 0 {
+		capacity = 1000
+	}
+
+	return &InboundQueue{
+		workers:  workers,
+		capacity: capacity,
+		process:  process,
+		tracker:  tracker,
+		mailbox:  mailbox,
+		queue:    goqueue.NewQueue[InboundJob](capacity),
+		busy:     make(map[string]bool),
+		parked:   make(map[string][]InboundJob),
+		notify:   make(chan struct{}, 1),
+		shutdown: make(chan struct{}),
+	}
+}
+
+// Start dispara os workers de processamento.
+func (q *InboundQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.worker(i + 1)
+	}
+}
+
+// Stop sinaliza shutdown; os workers esvaziam a fila antes de encerrar.
+func (q *InboundQueue) Stop() {
+	close(q.shutdown)
+	q.wg.Wait()
+}
+
+// Enqueue aceita um webhook para processamento. Retorna false se a fila estiver cheia.
+func (q *InboundQueue) Enqueue(job InboundJob) bool {
+	if q.tracker != nil {
+		q.tracker.Set(job.MessageID, job.Phone, InboundStatusQueued, nil)
+	}
+
+	// estacionados contam na capacidade
+	q.dispatchMu.Lock()
+	ok := q.queue.Len()+q.parkedN < q.capacity && q.queue.Enqueue(job)
+	q.dispatchMu.Unlock()
+
+	if !ok {
+		log.Printf("[inbound] fila cheia, recusando mensagem %s de %s", job.MessageID, job.Phone)
+		if q.tracker != nil {
+			q.tracker.Set(job.MessageID, job.Phone, InboundStatusFailed, errQueueFull)
+		}
+		return false
+	}
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (q *InboundQueue) worker(id int) {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.shutdown:
+			q.drain(id)
+			log.Printf("[inbound-worker-%d] encerrando", id)
+			return
+		case <-q.notify:
+			q.drain(id)
+		}
+	}
+}
+
+func (q *InboundQueue) drain(id int) {
+	for {
+		job, ok := q.next()
+		if !ok {
+			return
+		}
+		// o worker segue com a chave até esvaziar os jobs estacionados para ela
+		for ok {
+			q.mailbox.Do(job.Key, func() {
+				q.run(id, job)
+			})
+			job, ok = q.release(job.Key)
+		}
+	}
+}
+
+// next retira o próximo job de uma chave livre e a marca como ocupada; jobs de
+// chaves ocupadas são estacionados no caminho.
+func (q *InboundQueue) next() (InboundJob, bool) {
+	q.dispatchMu.Lock()
+	defer q.dispatchMu.Unlock()
+
+	for {
+		job, ok := q.queue.Dequeue()
+		if !ok {
+			return InboundJob{}, false
+		}
+		if q.busy[job.Key] {
+			q.parked[job.Key] = append(q.parked[job.Key], job)
+			q.parkedN++
+			continue
+		}
+		q.busy[job.Key] = true
+		return job, true
+	}
+}
+
+// release devolve o próximo job estacionado da chave ou, sem nenhum, a libera.
+func (q *InboundQueue) release(key string) (InboundJob, bool) {
+	q.dispatchMu.Lock()
+	defer q.dispatchMu.Unlock()
+
+	jobs := q.parked[key]
+	if len(jobs) == 0 {
+		delete(q.busy, key)
+		return InboundJob{}, false
+	}
+	if len(jobs) == 1 {
+		delete(q.parked, key)
+	} else {
+		q.parked[key] = jobs[1:]
+	}
+	q.parkedN--
+	return jobs[0], true
+}
+
+func (q *InboundQueue) run(id int, job InboundJob) {
+	if q.tracker != nil {
+		q.tracker.Set(job.MessageID, job.Phone, InboundStatusProcessing, nil)
+	}
+
+	status, err := q.process(context.Background(), job.Payload)
+	if err != nil {
+		log.Printf("[inbound-worker-%d] erro processando %s de %s: %v", id, job.MessageID, job.Phone, err)
+		status = InboundStatusFailed
+	}
+
+	if q.tracker != nil {
+		q.tracker.Set(job.MessageID, job.Phone, status, err)
+	}
+}
@@ -0,0 +1,32 @@
+package queue
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// NewInboundStatusHandler retorna um handler para consulta do processamento de uma mensagem recebida.
+func NewInboundStatusHandler(tracker *InboundTracker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		messageID := strings.TrimPrefix(r.URL.Path, "/inbound/")
+		if messageID == "" {
+			http.Error(w, "messageId requerido", http.StatusBadRequest)
+			return
+		}
+
+		info, ok := tracker.Get(messageID)
+		if !ok {
+			http.Error(w, "mensagem nao encontrada", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	})
+}
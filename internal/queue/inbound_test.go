@@ -0,0 +1,109 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"whatsapp-ia-integrator/internal/model"
+)
+
+func TestInboundQueueNoHeadOfLineBlocking(t *testing.T) {
+	release := make(chan struct{})
+	processed := make(chan string, 10)
+
+	var mu sync.Mutex
+	var order []string
+
+	q := NewInboundQueue(2, 10, nil, NewMailbox(), func(_ context.Context, p model.InboundWebhook) (InboundStatus, error) {
+		if p.MessageID == "a1" {
+			<-release
+		}
+		mu.Lock()
+		order = append(order, p.MessageID)
+		mu.Unlock()
+		processed <- p.MessageID
+		return InboundStatusDone, nil
+	})
+	q.Start()
+
+	enqueue := func(key, id string) {
+		t.Helper()
+		if !q.Enqueue(InboundJob{Key: key, MessageID: id, Payload: model.InboundWebhook{MessageID: id}}) {
+			t.Fatalf("fila recusou %s", id)
+		}
+	}
+
+	// a1 prende a chave "a"; a2 e a3 não podem ocupar o segundo worker
+	enqueue("a", "a1")
+	time.Sleep(20 * time.Millisecond)
+	enqueue("a", "a2")
+	enqueue("a", "a3")
+	enqueue("b", "b1")
+
+	select {
+	case id := <-processed:
+		if id != "b1" {
+			t.Fatalf("primeiro processado = %s, want b1", id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("b1 ficou preso atrás da chave a")
+	}
+
+	close(release)
+	q.Stop()
+
+	want := []string{"b1", "a1", "a2", "a3"}
+	if fmt.Sprint(order) != fmt.Sprint(want) {
+		t.Errorf("ordem = %v, want %v", order, want)
+	}
+}
+
+func TestInboundQueueCapacityCountsParked(t *testing.T) {
+	release := make(chan struct{})
+	q := NewInboundQueue(1, 2, nil, NewMailbox(), func(context.Context, model.InboundWebhook) (InboundStatus, error) {
+		<-release
+		return InboundStatusDone, nil
+	})
+	q.Start()
+	defer func() {
+		close(release)
+		q.Stop()
+	}()
+
+	q.Enqueue(InboundJob{Key: "a", MessageID: "a1"})
+	time.Sleep(20 * time.Millisecond)
+	if !q.Enqueue(InboundJob{Key: "a", MessageID: "a2"}) || !q.Enqueue(InboundJob{Key: "a", MessageID: "a3"}) {
+		t.Fatal("fila recusou dentro da capacidade")
+	}
+	if q.Enqueue(InboundJob{Key: "a", MessageID: "a4"}) {
+		t.Error("fila aceitou acima da capacidade")
+	}
+}
+
+func TestInboundTrackerEviction(t *testing.T) {
+	tracker := NewInboundTracker(time.Hour, 3)
+	for i := 1; i <= 5; i++ {
+		tracker.Set(fmt.Sprintf("m%d", i), "5511987654321", InboundStatusQueued, nil)
+	}
+	tracker.Set("m5", "", InboundStatusDone, nil)
+
+	for i, want := range []bool{false, false, true, true, true} {
+		if _, ok := tracker.Get(fmt.Sprintf("m%d", i+1)); ok != want {
+			t.Errorf("m%d presente = %v, want %v", i+1, ok, want)
+		}
+	}
+	if info, _ := tracker.Get("m5"); info.Status != InboundStatusDone {
+		t.Errorf("m5 status = %s, want done", info.Status)
+	}
+
+	expiring := NewInboundTracker(time.Millisecond, 100)
+	expiring.Set("velha", "", InboundStatusDone, nil)
+	time.Sleep(5 * time.Millisecond)
+	expiring.Set("nova", "", InboundStatusQueued, nil)
+	if _, ok := expiring.Get("velha"); ok {
+		t.Error("entrada fora da retenção não foi descartada")
+	}
+}
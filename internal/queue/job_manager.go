@@ -4,6 +4,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"whatsapp-ia-integrator/internal/phone"
 )
 
 // JobStatus representa o estado conhecido de um envio na CSA.
@@ -16,6 +18,8 @@ const (
 	JobStatusFailed    JobStatus = "failed"
 	JobStatusPending   JobStatus = "pending"
 	JobStatusEnqueued  JobStatus = "enqueued"
+	// JobStatusSuppressed: envio recusado porque o destino está na lista de supressão.
+	JobStatusSuppressed JobStatus = "suppressed"
 )
 
 // JobInfo agrega metadados de rastreamento.
@@ -38,12 +42,13 @@ func NewJobManager() *JobManager {
 }
 
 // UpsertStatus cria ou atualiza o status de um job.
-func (m *JobManager) UpsertStatus(messageID string, status JobStatus, phone, conversationID string) JobInfo {
+func (m *JobManager) UpsertStatus(messageID string, status JobStatus, phoneNumber, conversationID string) JobInfo {
 	if messageID == "" {
 		return JobInfo{}
 	}
 
 	normalized := JobStatus(strings.ToLower(string(status)))
+	phoneNumber = phone.Canonical(phoneNumber)
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -54,7 +59,7 @@ func (m *JobManager) UpsertStatus(messageID string, status JobStatus, phone, con
 	}
 
 	if info.Phone == "" {
-		info.Phone = phone
+		info.Phone = phoneNumber
 	}
 	if info.ConversationID == "" {
 		info.ConversationID = conversationID
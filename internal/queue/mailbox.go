@@ -0,0 +1,76 @@
+package queue
+
+import (
+	"log"
+	"sync"
+
+	goqueue "github.com/gothout/goqueue"
+)
+
+// Mailbox serializa tarefas por chave: tarefas da mesma chave rodam uma de cada vez,
+// na ordem de chegada, enquanto chaves diferentes rodam em paralelo.
+type Mailbox struct {
+	mu    sync.Mutex
+	boxes map[string]*goqueue.Queue[mailboxTask]
+}
+
+type mailboxTask struct {
+	fn   func()
+	done chan struct{}
+}
+
+func NewMailbox() *Mailbox {
+	return &Mailbox{boxes: make(map[string]*goqueue.Queue[mailboxTask])}
+}
+
+// Submit agenda fn na caixa da chave e retorna um canal fechado quando fn terminar.
+func (m *Mailbox) Submit(key string, fn func()) <-chan struct{} {
+	task := mailboxTask{fn: fn, done: make(chan struct{})}
+
+	m.mu.Lock()
+	box, running := m.boxes[key]
+	if !running {
+		box = goqueue.NewQueue[mailboxTask](0)
+		m.boxes[key] = box
+	}
+	box.Enqueue(task)
+	m.mu.Unlock()
+
+	if !running {
+		go m.drain(key, box)
+	}
+
+	return task.done
+}
+
+// Do executa fn na caixa da chave e aguarda o término.
+func (m *Mailbox) Do(key string, fn func()) {
+	<-m.Submit(key, fn)
+}
+
+// drain consome a caixa até esvaziar; a goroutine termina junto com a caixa.
+func (m *Mailbox) drain(key string, box *goqueue.Queue[mailboxTask]) {
+	for {
+		m.mu.Lock()
+		task, ok := box.Dequeue()
+		if !ok {
+			delete(m.boxes, key)
+			m.mu.Unlock()
+			return
+		}
+		m.mu.Unlock()
+
+		runTask(key, task)
+	}
+}
+
+func runTask(key string, task mailboxTask) {
+	defer close(task.done)
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[mailbox] panic processando chave %s: %v", key, r)
+		}
+	}()
+
+	task.fn()
+}
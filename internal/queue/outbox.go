@@ -2,47 +2,146 @@ package queue
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	goqueue "github.com/gothout/goqueue"
 
 	"whatsapp-ia-integrator/internal/csa"
+	"whatsapp-ia-integrator/internal/phone"
+	"whatsapp-ia-integrator/internal/suppress"
+	"whatsapp-ia-integrator/internal/transcript"
 )
 
-// OutboxJob é o item colocado na fila para envio à CSA.
+// OutboxJob é o item colocado na fila para envio à CSA. Type vazio equivale a "text";
+// os demais campos seguem csa.SendMessageRequest.
 type OutboxJob struct {
-	Phone          string
+	// ID identifica o envio em /jobs/{id}; gerado no Enqueue quando vazio.
+	ID string
+	// Phone identifica o contato (E.164); Destination é o formato usado no envio
+	// à CSA e, se vazio, recebe o Phone original no Enqueue.
+	Phone       string
+	Destination string
+	// Channel é o canal da CSA (csa.channels); vazio equivale a "whatsapp".
+	Channel        string
 	ConversationID string
+	Type           string
 	Text           string
+
+	URL       string
+	Filename  string
+	Caption   string
+	Latitude  string
+	Longitude string
+	Address   string
+
+	// Interactive carrega botões ou lista quando Type é "interactive".
+	Interactive *csa.Interactive
+
+	// BypassSuppression permite enviar a quem está na lista de supressão
+	// (ex.: a confirmação do próprio opt-out).
+	BypassSuppression bool
+
+	// Part/Parts indicam a posição quando uma resposta longa é quebrada (1-based).
+	Part  int
+	Parts int
+}
+
+// Summary descreve o job em texto (usado na transcrição e em logs).
+func (j OutboxJob) Summary() string {
+	switch j.Type {
+	case "", "text":
+		return j.Text
+	case "location":
+		return strings.TrimSpace(fmt.Sprintf("[location] %s,%s %s", j.Latitude, j.Longitude, j.Address))
+	case "interactive":
+		if j.Interactive == nil {
+			return "[interactive]"
+		}
+		var options []string
+		for _, b := range j.Interactive.Buttons {
+			options = append(options, b.Title)
+		}
+		for _, section := range j.Interactive.Sections {
+			for _, row := range section.Rows {
+				options = append(options, row.Title)
+			}
+		}
+		return fmt.Sprintf("[interactive] %s [%s]", j.Interactive.Body, strings.Join(options, " | "))
+	default:
+		parts := []string{"[" + j.Type + "]", j.URL}
+		if j.Filename != "" {
+			parts = append(parts, j.Filename)
+		}
+		if j.Caption != "" {
+			parts = append(parts, j.Caption)
+		}
+		return strings.Join(parts, " ")
+	}
+}
+
+func (j OutboxJob) request() *csa.SendMessageRequest {
+	req := &csa.SendMessageRequest{
+		Channel:     j.Channel,
+		Destination: j.Destination,
+		Type:        j.Type,
+		Text:        j.Text,
+		URL:         j.URL,
+		Filename:    j.Filename,
+		Caption:     j.Caption,
+		Latitude:    j.Latitude,
+		Longitude:   j.Longitude,
+		Address:     j.Address,
+		Interactive: j.Interactive,
+	}
+	if req.Destination == "" {
+		req.Destination = j.Phone
+	}
+	if req.Type == "" {
+		req.Type = "text"
+	}
+	req.Preview = req.Type == "text"
+	return req
 }
 
 // Outbox é uma fila de saída com workers concorrentes, apoiada pelo goqueue para armazenar mensagens.
+// Mensagens para o mesmo telefone são enviadas na ordem em que foram enfileiradas.
 type Outbox struct {
-	csa     *csa.Client
-	workers int
-	jobs    *JobManager
-
-	queue    *goqueue.Queue[OutboxJob]
-	notify   chan struct{}
-	wg       sync.WaitGroup
-	shutdown chan struct{}
+	csa         *csa.Client
+	workers     int
+	jobs        *JobManager
+	transcripts *transcript.Store
+	suppressed  *suppress.List
+
+	queue      *goqueue.Queue[OutboxJob]
+	dispatchMu sync.Mutex
+	serial     *Mailbox
+	notify     chan struct{}
+	wg         sync.WaitGroup
+	shutdown   chan struct{}
 }
 
 // NewOutbox cria a fila e inicializa os canais.
-func NewOutbox(csaClient *csa.Client, workers int, jobManager *JobManager) *Outbox {
+func NewOutbox(csaClient *csa.Client, workers int, jobManager *JobManager, transcripts *transcript.Store, suppressed *suppress.List) *Outbox {
 	if workers <= 0 {
 		workers = 3
 	}
 
 	return &Outbox{
-		csa:      csaClient,
-		workers:  workers,
-		jobs:     jobManager,
-		queue:    goqueue.NewQueue[OutboxJob](100),
-		notify:   make(chan struct{}, 1),
-		shutdown: make(chan struct{}),
+		csa:         csaClient,
+		workers:     workers,
+		jobs:        jobManager,
+		transcripts: transcripts,
+		suppressed:  suppressed,
+		queue:       goqueue.NewQueue[OutboxJob](100),
+		serial:      NewMailbox(),
+		notify:      make(chan struct{}, 1),
+		shutdown:    make(chan struct{}),
 	}
 }
 
@@ -61,17 +160,28 @@ func (o *Outbox) Stop() {
 	o.wg.Wait()
 }
 
-// Enqueue adiciona um job na fila.
-func (o *Outbox) Enqueue(job OutboxJob) {
+// Enqueue adiciona um job na fila e retorna o ID para consulta em /jobs/{id}.
+func (o *Outbox) Enqueue(job OutboxJob) string {
+	if job.ID == "" {
+		job.ID = newJobID()
+	}
+	if job.Destination == "" {
+		job.Destination = job.Phone
+	}
+	job.Phone = phone.Canonical(job.Phone)
+
 	if ok := o.queue.Enqueue(job); !ok {
 		log.Printf("[outbox] fila cheia, descartando mensagem para %s", job.Phone)
-		return
+		o.setStatus(job.ID, JobStatusFailed, job)
+		return job.ID
 	}
+	o.setStatus(job.ID, JobStatusEnqueued, job)
 
 	select {
 	case o.notify <- struct{}{}:
 	default:
 	}
+	return job.ID
 }
 
 func (o *Outbox) worker(id int) {
@@ -89,35 +199,89 @@ func (o *Outbox) worker(id int) {
 			}
 
 			for {
+				// retirar da fila e entrar na mailbox de forma atômica mantém a
+				// ordem de envio por telefone (ex.: partes de uma resposta longa)
+				o.dispatchMu.Lock()
 				job, ok := o.queue.Dequeue()
 				if !ok {
+					o.dispatchMu.Unlock()
 					break
 				}
-
-				ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
-				resp, err := o.csa.SendMessage(ctx, &csa.SendMessageRequest{
-					Destination: job.Phone,
-					Text:        job.Text,
-					Type:        "text",
-					Preview:     true,
+				done := o.serial.Submit(job.Phone, func() {
+					o.send(id, job)
 				})
-				cancel()
+				o.dispatchMu.Unlock()
 
-				if err != nil {
-					log.Printf("[outbox-worker-%d] erro enviando para %s (conv %s): %v", id, job.Phone, job.ConversationID, err)
-					continue
-				}
+				<-done
+			}
+		}
+	}
+}
 
-				if o.jobs != nil && resp != nil {
-					status := JobStatus(resp.Status)
-					if status == "" {
-						status = JobStatusSubmitted
-					}
-					o.jobs.UpsertStatus(resp.MessageID, status, job.Phone, job.ConversationID)
-				}
+func (o *Outbox) send(id int, job OutboxJob) {
+	if !job.BypassSuppression && o.suppressed.Suppressed(job.Phone) {
+		o.refuse(id, job)
+		return
+	}
 
-				log.Printf("[outbox-worker-%d] mensagem enviada para %s (conv %s)", id, job.Phone, job.ConversationID)
-			}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	resp, err := o.csa.SendMessage(ctx, job.request())
+	cancel()
+
+	if err != nil {
+		log.Printf("[outbox-worker-%d] erro enviando para %s (conv %s): %v", id, job.Phone, job.ConversationID, err)
+		o.setStatus(job.ID, JobStatusFailed, job)
+		if o.transcripts != nil {
+			o.transcripts.RecordOutbound(job.Phone, job.ConversationID, "", job.Summary(), string(JobStatusFailed))
 		}
+		return
+	}
+
+	status := JobStatusSubmitted
+	messageID := ""
+	if resp != nil {
+		messageID = resp.MessageID
+		if resp.Status != "" {
+			status = JobStatus(resp.Status)
+		}
+	}
+
+	// o ID do job segue o status; os webhooks de status da CSA usam o messageId
+	o.setStatus(job.ID, status, job)
+	if o.jobs != nil && resp != nil {
+		o.jobs.UpsertStatus(messageID, status, job.Phone, job.ConversationID)
+	}
+	if o.transcripts != nil {
+		o.transcripts.RecordOutbound(job.Phone, job.ConversationID, messageID, job.Summary(), string(status))
+	}
+
+	if job.Parts > 1 {
+		log.Printf("[outbox-worker-%d] parte %d/%d enviada para %s (conv %s)", id, job.Part, job.Parts, job.Phone, job.ConversationID)
+		return
+	}
+	log.Printf("[outbox-worker-%d] mensagem enviada para %s (conv %s)", id, job.Phone, job.ConversationID)
+}
+
+// refuse registra o envio recusado para um destino suprimido.
+func (o *Outbox) refuse(id int, job OutboxJob) {
+	log.Printf("[outbox-worker-%d] envio para %s recusado: contato na lista de supressão", id, job.Phone)
+
+	o.setStatus(job.ID, JobStatusSuppressed, job)
+	if o.transcripts != nil {
+		o.transcripts.RecordOutbound(job.Phone, job.ConversationID, "", job.Summary(), string(JobStatusSuppressed))
+	}
+}
+
+func (o *Outbox) setStatus(id string, status JobStatus, job OutboxJob) {
+	if o.jobs != nil {
+		o.jobs.UpsertStatus(id, status, job.Phone, job.ConversationID)
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("job-%d", time.Now().UnixNano())
 	}
+	return "job-" + hex.EncodeToString(b)
 }
@@ -0,0 +1,41 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"whatsapp-ia-integrator/internal/suppress"
+)
+
+func TestOutboxSuppressedJobKeepsItsID(t *testing.T) {
+	list, err := suppress.NewList("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list.Add("5511987654321", suppress.ReasonOptOut, "teste")
+
+	jobs := NewJobManager()
+	o := NewOutbox(nil, 1, jobs, nil, list)
+	o.Start()
+	defer o.Stop()
+
+	id := o.Enqueue(OutboxJob{Phone: "5511987654321", ConversationID: "c1", Text: "oi"})
+	if id == "" {
+		t.Fatal("Enqueue sem ID")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		info, ok := jobs.Get(id)
+		if ok && info.Status == JobStatusSuppressed {
+			if info.Phone != "+5511987654321" || info.ConversationID != "c1" {
+				t.Errorf("job = %+v", info)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s = %+v, %v; want suppressed", id, info, ok)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
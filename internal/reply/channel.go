@@ -0,0 +1,104 @@
+package reply
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"whatsapp-ia-integrator/internal/csa"
+)
+
+// formatos de texto aceitos em csa.channels
+const (
+	FormatWhatsApp = "whatsapp"
+	FormatMarkdown = "markdown"
+	FormatPlain    = "plain"
+)
+
+var (
+	waCode   = regexp.MustCompile("(?s)```(.*?)```")
+	waBold   = regexp.MustCompile(`(^|[^\w])\*([^*\s](?:[^*\n]*?[^*\s])?)\*([^\w]|$)`)
+	waItalic = regexp.MustCompile(`(^|[^\w])_([^_\n]+?)_([^\w]|$)`)
+	waStrike = regexp.MustCompile(`~([^~\n]+?)~`)
+)
+
+// FormatFor converte o Markdown da IA para o formato do canal. Formatos
+// desconhecidos seguem o do WhatsApp.
+func FormatFor(format, text string) string {
+	switch format {
+	case FormatMarkdown:
+		return strings.TrimSpace(text)
+	case FormatPlain:
+		return PlainText(text)
+	default:
+		return Format(text)
+	}
+}
+
+// PlainText remove a formatação: aplica Format (listas, links e tabelas) e
+// descarta os marcadores de negrito, itálico e riscado. Código perde só as
+// crases; o conteúdo segue intacto.
+func PlainText(text string) string {
+	text = Format(text)
+
+	var b strings.Builder
+	last := 0
+	for _, m := range waCode.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(stripMarkers(text[last:m[0]]))
+		b.WriteString(text[m[2]:m[3]])
+		last = m[1]
+	}
+	b.WriteString(stripMarkers(text[last:]))
+	return strings.TrimSpace(b.String())
+}
+
+// stripMarkers remove negrito, itálico e riscado. As bordas das expressões
+// consomem o caractere vizinho, então repete até estabilizar para pegar trechos
+// separados por um só espaço ou aninhados.
+func stripMarkers(text string) string {
+	for {
+		next := waBold.ReplaceAllString(text, "${1}${2}${3}")
+		next = waItalic.ReplaceAllString(next, "${1}${2}${3}")
+		next = waStrike.ReplaceAllString(next, "$1")
+		if next == text {
+			return text
+		}
+		text = next
+	}
+}
+
+// InteractiveAsText descreve botões ou lista como texto com opções numeradas,
+// para canais sem suporte a mensagens interativas.
+func InteractiveAsText(in *csa.Interactive) string {
+	if in == nil {
+		return ""
+	}
+
+	var options []string
+	for _, b := range in.Buttons {
+		options = append(options, b.Title)
+	}
+	for _, section := range in.Sections {
+		for _, row := range section.Rows {
+			option := row.Title
+			if row.Description != "" {
+				option += " - " + row.Description
+			}
+			options = append(options, option)
+		}
+	}
+
+	var b strings.Builder
+	for _, s := range []string{in.Header, in.Body} {
+		if s = strings.TrimSpace(s); s != "" {
+			b.WriteString(s + "\n\n")
+		}
+	}
+	for i, option := range options {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, option)
+	}
+	if footer := strings.TrimSpace(in.Footer); footer != "" {
+		b.WriteString("\n" + footer)
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,142 @@
+package reply
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	codeFence    = regexp.MustCompile("(?s)```[a-zA-Z0-9_+-]*\\n?(.*?)```")
+	inlineCode   = regexp.MustCompile("`([^`\n]+)`")
+	heading      = regexp.MustCompile(`(?m)^[ \t]{0,3}#{1,6}[ \t]+(.+?)[ \t]*#*[ \t]*$`)
+	boldItalic   = regexp.MustCompile(`\*\*\*([^*\n]+?)\*\*\*|___([^_\n]+?)___`)
+	boldStars    = regexp.MustCompile(`\*\*([^*\n]+?)\*\*`)
+	boldUnder    = regexp.MustCompile(`__([^_\n]+?)__`)
+	italicStar   = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*\n]*?)\*([^*\w]|$)`)
+	strike       = regexp.MustCompile(`~~([^~\n]+?)~~`)
+	bullet       = regexp.MustCompile(`(?m)^([ \t]*)[-*+][ \t]+`)
+	horizontal   = regexp.MustCompile(`(?m)^[ \t]*([-*_][ \t]*){3,}$`)
+	imageOrLink  = regexp.MustCompile(`!?\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	tableDivider = regexp.MustCompile(`^\|?\s*:?-{2,}:?\s*(\|\s*:?-{2,}:?\s*)*\|?$`)
+)
+
+// marcador temporário para o negrito, evita que vire itálico no passo seguinte
+const boldMark = "\x00"
+
+// Format converte o Markdown devolvido pela IA para a formatação do WhatsApp:
+// **negrito** vira *negrito*, *itálico* vira _itálico_, ***ambos*** vira *_ambos_*,
+// ~~riscado~~ vira ~riscado~, títulos viram negrito, listas usam "•", links viram
+// "texto: url" e tabelas viram linhas "coluna: valor". Blocos de código são preservados em monoespaçado.
+func Format(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	// código não passa pelas demais conversões
+	var code []string
+	keep := func(s string) string {
+		code = append(code, s)
+		return fmt.Sprintf("\x01%d\x01", len(code)-1)
+	}
+	text = codeFence.ReplaceAllStringFunc(text, func(m string) string {
+		body := codeFence.FindStringSubmatch(m)[1]
+		return keep("```" + strings.TrimRight(body, "\n") + "```")
+	})
+	text = inlineCode.ReplaceAllStringFunc(text, func(m string) string {
+		return keep("```" + inlineCode.FindStringSubmatch(m)[1] + "```")
+	})
+
+	text = formatTables(text)
+
+	text = imageOrLink.ReplaceAllStringFunc(text, func(m string) string {
+		sub := imageOrLink.FindStringSubmatch(m)
+		label, url := strings.TrimSpace(sub[1]), sub[2]
+		if label == "" || label == url {
+			return url
+		}
+		return label + ": " + url
+	})
+
+	text = horizontal.ReplaceAllString(text, "")
+	text = heading.ReplaceAllStringFunc(text, func(m string) string {
+		// o título já vira negrito; o negrito dentro dele (## **Planos**) se funde
+		// ao do título em vez de fechá-lo
+		title := heading.FindStringSubmatch(m)[1]
+		title = boldItalic.ReplaceAllString(title, "_${1}${2}_")
+		title = boldStars.ReplaceAllString(title, "$1")
+		title = boldUnder.ReplaceAllString(title, "$1")
+		return boldMark + title + boldMark
+	})
+	text = boldItalic.ReplaceAllString(text, boldMark+"_${1}${2}_"+boldMark)
+	text = boldStars.ReplaceAllString(text, boldMark+"$1"+boldMark)
+	text = boldUnder.ReplaceAllString(text, boldMark+"$1"+boldMark)
+	text = bullet.ReplaceAllString(text, "$1• ")
+	text = italicStar.ReplaceAllString(text, "${1}_${2}_${3}")
+	text = strike.ReplaceAllString(text, "~$1~")
+	text = strings.ReplaceAll(text, boldMark+boldMark, "")
+	text = strings.ReplaceAll(text, boldMark, "*")
+
+	for i, c := range code {
+		text = strings.Replace(text, fmt.Sprintf("\x01%d\x01", i), c, 1)
+	}
+
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
+
+// formatTables troca tabelas Markdown por blocos "coluna: valor", um por linha da tabela.
+func formatTables(text string) string {
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+
+	for i := 0; i < len(lines); i++ {
+		if i+1 >= len(lines) || !isTableRow(lines[i]) || !tableDivider.MatchString(strings.TrimSpace(lines[i+1])) {
+			out = append(out, lines[i])
+			continue
+		}
+
+		header := tableCells(lines[i])
+		i += 2
+
+		var rows []string
+		for ; i < len(lines) && isTableRow(lines[i]); i++ {
+			cells := tableCells(lines[i])
+			var row []string
+			for j, cell := range cells {
+				if cell == "" {
+					continue
+				}
+				if j < len(header) && header[j] != "" {
+					row = append(row, header[j]+": "+cell)
+				} else {
+					row = append(row, cell)
+				}
+			}
+			if len(row) > 0 {
+				rows = append(rows, strings.Join(row, "\n"))
+			}
+		}
+		i--
+
+		out = append(out, strings.Join(rows, "\n\n"))
+	}
+	return strings.Join(out, "\n")
+}
+
+func isTableRow(line string) bool {
+	line = strings.TrimSpace(line)
+	return strings.HasPrefix(line, "|") && strings.Count(line, "|") >= 2
+}
+
+func tableCells(line string) []string {
+	line = strings.TrimSpace(line)
+	line = strings.TrimPrefix(line, "|")
+	line = strings.TrimSuffix(line, "|")
+
+	cells := strings.Split(line, "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(c)
+	}
+	return cells
+}
@@ -0,0 +1,87 @@
+package reply
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "regrava os arquivos .golden de testdata")
+
+// TestFormatGolden converte as respostas em testdata/*.md e compara com
+// <nome>.golden (WhatsApp) e <nome>.plain.golden (texto puro).
+func TestFormatGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("nenhum caso em testdata")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".md")
+		raw, err := os.ReadFile(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cases := []struct {
+			format, golden string
+		}{
+			{FormatWhatsApp, name + ".golden"},
+			{FormatPlain, name + ".plain.golden"},
+		}
+		for _, c := range cases {
+			t.Run(c.golden, func(t *testing.T) {
+				got := FormatFor(c.format, string(raw)) + "\n"
+				golden := filepath.Join("testdata", c.golden)
+				if *update {
+					if err := os.WriteFile(golden, []byte(got), 0o644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (rode com -update para gerar)", err)
+				}
+				if got != string(want) {
+					t.Errorf("FormatFor(%q, %s) diverge de %s\n--- got ---\n%s--- want ---\n%s", c.format, input, golden, got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestFormatFor(t *testing.T) {
+	tests := []struct {
+		name, format, in, want string
+	}{
+		{"markdown preservado", FormatMarkdown, "  **negrito** e [link](https://x.com)\n", "**negrito** e [link](https://x.com)"},
+		{"formato vazio segue whatsapp", "", "**negrito**", "*negrito*"},
+		{"formato desconhecido segue whatsapp", "sms", "~~riscado~~", "~riscado~"},
+		{"negrito e itálico", FormatWhatsApp, "***x***", "*_x_*"},
+		{"negrito e itálico com sublinhado", FormatWhatsApp, "___x___", "*_x_*"},
+		{"itálico com negrito dentro", FormatWhatsApp, "*muito **bom** mesmo*", "_muito *bom* mesmo_"},
+		{"snake_case intacto", FormatWhatsApp, "campo user_id e MAX_RETRY", "campo user_id e MAX_RETRY"},
+		{"snake_case intacto no texto puro", FormatPlain, "campo user_id e *ok*", "campo user_id e ok"},
+		{"texto puro sem marcadores", FormatPlain, "***x*** e ~~y~~ e `z`", "x e y e z"},
+		{"asteriscos de conta no texto puro", FormatPlain, "preço: 2 * 3 * 4", "preço: 2 * 3 * 4"},
+		{"asterisco no meio da palavra", FormatPlain, "use a*b*c no filtro", "use a*b*c no filtro"},
+		{"negritos vizinhos no texto puro", FormatPlain, "**a** **b**", "a b"},
+		{"itálico com negrito dentro no texto puro", FormatPlain, "_*x*_", "x"},
+		{"vazio", FormatWhatsApp, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatFor(tt.format, tt.in); got != tt.want {
+				t.Errorf("FormatFor(%q, %q) = %q, want %q", tt.format, tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package reply
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"whatsapp-ia-integrator/internal/csa"
+)
+
+// limites do WhatsApp para mensagens interativas
+const (
+	maxButtons        = 3
+	maxButtonTitle    = 20
+	maxListRows       = 10
+	maxRowTitle       = 24
+	maxRowDescription = 72
+)
+
+// DefaultListButton é o rótulo do botão que abre a lista quando a IA não informa.
+const DefaultListButton = "Ver opções"
+
+// parseInteractive monta botões ou lista a partir da diretiva da IA:
+//
+//	{"type":"buttons","text":"...","buttons":[{"id":"1","title":"Sim"}]}
+//	{"type":"list","text":"...","button":"Ver planos","sections":[{"title":"...","rows":[{"id","title","description"}]}]}
+//
+// "rows" sem sections vira uma seção única. Opções podem ser apenas strings
+// (o título também é usado como id). Excessos são cortados nos limites do WhatsApp.
+func parseInteractive(obj map[string]any) *csa.Interactive {
+	in := &csa.Interactive{
+		Header: str(obj["header"]),
+		Body:   firstNonEmpty(str(obj["text"]), str(obj["body"])),
+		Footer: str(obj["footer"]),
+	}
+	if in.Body == "" {
+		return nil
+	}
+
+	kind := strings.ToLower(str(obj["type"]))
+	_, hasSections := obj["sections"]
+	_, hasRows := obj["rows"]
+	if kind == "list" || hasSections || hasRows {
+		in.Type = "list"
+		in.ButtonText = truncate(firstNonEmpty(str(obj["button"]), DefaultListButton), maxButtonTitle)
+		in.Sections = parseSections(obj)
+		if len(in.Sections) == 0 {
+			return nil
+		}
+		return in
+	}
+
+	in.Type = "button"
+	for _, item := range list(obj["buttons"]) {
+		if len(in.Buttons) == maxButtons {
+			break
+		}
+		id, title, _ := option(item)
+		if title != "" {
+			in.Buttons = append(in.Buttons, csa.Button{ID: id, Title: truncate(title, maxButtonTitle)})
+		}
+	}
+	if len(in.Buttons) == 0 {
+		return nil
+	}
+	return in
+}
+
+func parseSections(obj map[string]any) []csa.ListSection {
+	sections := list(obj["sections"])
+	if sections == nil {
+		sections = []any{map[string]any{"rows": obj["rows"]}}
+	}
+
+	total := 0
+	var out []csa.ListSection
+	for _, item := range sections {
+		s, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		section := csa.ListSection{Title: truncate(str(s["title"]), maxRowTitle)}
+		for _, row := range list(s["rows"]) {
+			if total == maxListRows {
+				break
+			}
+			id, title, description := option(row)
+			if title == "" {
+				continue
+			}
+			section.Rows = append(section.Rows, csa.ListRow{
+				ID:          id,
+				Title:       truncate(title, maxRowTitle),
+				Description: truncate(description, maxRowDescription),
+			})
+			total++
+		}
+		if len(section.Rows) > 0 {
+			out = append(out, section)
+		}
+	}
+	return out
+}
+
+// option lê uma opção no formato {"id","title","description"} ou string.
+func option(v any) (id, title, description string) {
+	switch o := v.(type) {
+	case string:
+		title = strings.TrimSpace(o)
+		return title, title, ""
+	case map[string]any:
+		title = str(o["title"])
+		id = firstNonEmpty(str(o["id"]), title)
+		return id, title, str(o["description"])
+	}
+	return "", "", ""
+}
+
+func list(v any) []any {
+	items, _ := v.([]any)
+	return items
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return strings.TrimSpace(string([]rune(s)[:limit-1])) + "…"
+}
@@ -0,0 +1,166 @@
+package reply
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"whatsapp-ia-integrator/internal/queue"
+)
+
+var (
+	// ![legenda](https://...)
+	markdownImage = regexp.MustCompile(`!\[([^\]]*)\]\((https?://[^\s)]+)\)`)
+	// [texto](https://.../arquivo.pdf)
+	markdownLink = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s)]+)\)`)
+	// linhas que sobraram vazias depois de remover anexos
+	blankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+var documentExtensions = map[string]bool{
+	".pdf": true, ".doc": true, ".docx": true, ".xls": true, ".xlsx": true,
+	".ppt": true, ".pptx": true, ".csv": true, ".txt": true, ".zip": true,
+}
+
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true,
+}
+
+// Build transforma a resposta da IA em jobs de envio: o texto (sem os anexos)
+// seguido das imagens/documentos referenciados em Markdown e das mensagens
+// estruturadas em metadata["whatsapp"] (objeto ou lista de objetos com
+// type, url, filename, caption, latitude, longitude, address, text; botões e
+// listas seguem parseInteractive).
+// Phone e ConversationID ficam a cargo de quem chama.
+func Build(answer string, metadata map[string]any) []queue.OutboxJob {
+	text, attachments := extractMarkdown(answer)
+
+	var jobs []queue.OutboxJob
+	if text != "" {
+		jobs = append(jobs, queue.OutboxJob{Type: "text", Text: text})
+	}
+	jobs = append(jobs, attachments...)
+	jobs = append(jobs, fromMetadata(metadata)...)
+	return jobs
+}
+
+func extractMarkdown(answer string) (string, []queue.OutboxJob) {
+	var jobs []queue.OutboxJob
+
+	text := markdownImage.ReplaceAllStringFunc(answer, func(match string) string {
+		m := markdownImage.FindStringSubmatch(match)
+		jobs = append(jobs, queue.OutboxJob{Type: "image", URL: m[2], Caption: strings.TrimSpace(m[1])})
+		return ""
+	})
+
+	text = markdownLink.ReplaceAllStringFunc(text, func(match string) string {
+		m := markdownLink.FindStringSubmatch(match)
+		switch ext := urlExtension(m[2]); {
+		case documentExtensions[ext]:
+			jobs = append(jobs, queue.OutboxJob{Type: "document", URL: m[2], Filename: urlFilename(m[2]), Caption: strings.TrimSpace(m[1])})
+			return ""
+		case imageExtensions[ext]:
+			jobs = append(jobs, queue.OutboxJob{Type: "image", URL: m[2], Caption: strings.TrimSpace(m[1])})
+			return ""
+		}
+		return match
+	})
+
+	text = blankLines.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text), jobs
+}
+
+func fromMetadata(metadata map[string]any) []queue.OutboxJob {
+	if metadata == nil {
+		return nil
+	}
+
+	var items []any
+	switch v := metadata["whatsapp"].(type) {
+	case []any:
+		items = v
+	case map[string]any:
+		items = []any{v}
+	}
+
+	jobs := make([]queue.OutboxJob, 0, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		job := queue.OutboxJob{
+			Type:      strings.ToLower(str(obj["type"])),
+			Text:      str(obj["text"]),
+			URL:       str(obj["url"]),
+			Filename:  str(obj["filename"]),
+			Caption:   str(obj["caption"]),
+			Latitude:  str(obj["latitude"]),
+			Longitude: str(obj["longitude"]),
+			Address:   str(obj["address"]),
+		}
+		if job.Type == "document" && job.Filename == "" && job.URL != "" {
+			job.Filename = urlFilename(job.URL)
+		}
+		switch job.Type {
+		case "button", "buttons", "list", "interactive":
+			job.Type = "interactive"
+			job.Text = ""
+			job.Interactive = parseInteractive(obj)
+		}
+		if valid(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// valid descarta diretivas incompletas vindas da IA.
+func valid(job queue.OutboxJob) bool {
+	switch job.Type {
+	case "text":
+		return job.Text != ""
+	case "image", "document", "audio", "video", "sticker":
+		return job.URL != ""
+	case "location":
+		return job.Latitude != "" && job.Longitude != ""
+	case "interactive":
+		return job.Interactive != nil
+	}
+	return false
+}
+
+func str(v any) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	return ""
+}
+
+func urlExtension(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(path.Ext(u.Path))
+}
+
+func urlFilename(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
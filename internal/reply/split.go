@@ -0,0 +1,165 @@
+package reply
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxLength é o limite de caracteres de uma mensagem de texto do WhatsApp.
+const DefaultMaxLength = 4096
+
+// trechos que nunca podem ser partidos ao meio
+var protectedSpans = []*regexp.Regexp{
+	regexp.MustCompile("(?s)```.*?```"),
+	regexp.MustCompile("`[^`\n]+`"),
+	regexp.MustCompile(`\[[^\]\n]*\]\([^)\s]*\)`),
+	regexp.MustCompile(`https?://\S+`),
+	regexp.MustCompile(`\*[^*\n]+\*`),
+	regexp.MustCompile(`_[^_\n]+_`),
+	regexp.MustCompile(`~[^~\n]+~`),
+}
+
+// fronteiras de quebra em ordem de preferência
+var sentenceEnd = regexp.MustCompile(`[.!?…]["')\]]*\s`)
+
+// SplitOptions controla a quebra de respostas longas.
+type SplitOptions struct {
+	// MaxLength em caracteres (runes) por parte; <= 0 usa DefaultMaxLength.
+	MaxLength int
+	// Numbering acrescenta " (1/3)" ao final de cada parte.
+	Numbering bool
+}
+
+// Split quebra o texto em partes de até MaxLength caracteres, preferindo
+// parágrafos, depois linhas, frases e por fim espaços. URLs, links e trechos
+// de formatação (*negrito*, _itálico_, `código`) não são partidos.
+func Split(text string, opts SplitOptions) []string {
+	maxLen := opts.MaxLength
+	if maxLen <= 0 {
+		maxLen = DefaultMaxLength
+	}
+
+	text = strings.TrimSpace(text)
+	if utf8.RuneCountInString(text) <= maxLen {
+		if text == "" {
+			return nil
+		}
+		return []string{text}
+	}
+
+	limit := maxLen
+	if opts.Numbering {
+		// reserva espaço para " (99/99)"
+		limit -= 8
+	}
+	if limit < 1 {
+		limit = 1
+	}
+
+	var parts []string
+	for rest := text; rest != ""; {
+		if utf8.RuneCountInString(rest) <= limit {
+			parts = append(parts, rest)
+			break
+		}
+
+		cut := breakPoint(rest, limit)
+		parts = append(parts, strings.TrimSpace(rest[:cut]))
+		rest = strings.TrimSpace(rest[cut:])
+	}
+
+	if opts.Numbering && len(parts) > 1 {
+		for i := range parts {
+			parts[i] = fmt.Sprintf("%s (%d/%d)", parts[i], i+1, len(parts))
+		}
+	}
+	return parts
+}
+
+// breakPoint retorna o índice (em bytes) onde cortar rest para que a primeira
+// parte tenha no máximo limit runes.
+func breakPoint(rest string, limit int) int {
+	window := byteOffset(rest, limit)
+	spans := spansOf(rest[:min(len(rest), window+512)])
+
+	allowed := func(i int) bool {
+		if i <= 0 || i > window {
+			return false
+		}
+		for _, s := range spans {
+			if i > s[0] && i < s[1] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// não aceita partes minúsculas só para respeitar a fronteira preferida
+	minCut := window / 3
+
+	candidates := [][]int{
+		lastIndexes(rest[:window], "\n\n", 2),
+		lastIndexes(rest[:window], "\n", 1),
+		sentenceEnds(rest[:window]),
+		lastIndexes(rest[:window], " ", 1),
+	}
+	for _, list := range candidates {
+		for i := len(list) - 1; i >= 0; i-- {
+			if list[i] >= minCut && allowed(list[i]) {
+				return list[i]
+			}
+		}
+	}
+
+	// sem fronteira válida: corta antes do trecho protegido que cruza a janela
+	for _, s := range spans {
+		if window > s[0] && window < s[1] && s[0] > 0 {
+			return s[0]
+		}
+	}
+	return window
+}
+
+func spansOf(text string) [][2]int {
+	var spans [][2]int
+	for _, re := range protectedSpans {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			spans = append(spans, [2]int{loc[0], loc[1]})
+		}
+	}
+	return spans
+}
+
+// lastIndexes retorna as posições logo após cada ocorrência de sep.
+func lastIndexes(text, sep string, width int) []int {
+	var out []int
+	for i := 0; ; {
+		j := strings.Index(text[i:], sep)
+		if j < 0 {
+			return out
+		}
+		out = append(out, i+j+width)
+		i += j + len(sep)
+	}
+}
+
+func sentenceEnds(text string) []int {
+	var out []int
+	for _, loc := range sentenceEnd.FindAllStringIndex(text, -1) {
+		out = append(out, loc[1])
+	}
+	return out
+}
+
+// byteOffset converte uma quantidade de runes em offset de bytes.
+func byteOffset(text string, runes int) int {
+	for i := range text {
+		if runes == 0 {
+			return i
+		}
+		runes--
+	}
+	return len(text)
+}
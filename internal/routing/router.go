@@ -0,0 +1,115 @@
+package routing
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/phone"
+)
+
+// DefaultRoute é o nome da decisão quando nenhuma rota seleciona o contato.
+const DefaultRoute = "default"
+
+// Decision é o resultado do roteamento de um contato. Campos vazios mantêm
+// a configuração padrão do Chatvolt.
+type Decision struct {
+	Route       string
+	Reason      string
+	AgentID     string
+	ModelName   string
+	Temperature *float64
+}
+
+type route struct {
+	cfg    config.RouteConfig
+	phones map[string]bool
+	to     map[string]bool
+}
+
+// Router escolhe o agente por contato. Um Router nil sempre decide pelo padrão.
+type Router struct {
+	routes []route
+}
+
+// New valida as rotas configuradas.
+func New(routes []config.RouteConfig) (*Router, error) {
+	r := &Router{}
+	for i, cfg := range routes {
+		if cfg.Name == "" {
+			cfg.Name = fmt.Sprintf("route-%d", i+1)
+		}
+		if cfg.Percent < 0 || cfg.Percent > 100 {
+			return nil, fmt.Errorf("rota %s: percent deve estar entre 0 e 100", cfg.Name)
+		}
+		if cfg.AgentID == "" && cfg.ModelName == "" && cfg.Temperature == nil {
+			return nil, fmt.Errorf("rota %s: nada a alterar (agent_id, model_name ou temperature)", cfg.Name)
+		}
+
+		rt := route{cfg: cfg, phones: digitSet(cfg.Phones), to: digitSet(cfg.To)}
+		r.routes = append(r.routes, rt)
+	}
+	return r, nil
+}
+
+// Decide escolhe a rota do telefone, considerando também o número de destino.
+func (r *Router) Decide(phone, to string) Decision {
+	if r != nil {
+		phone, to = canonical(phone), canonical(to)
+		for _, rt := range r.routes {
+			if reason, ok := rt.match(phone, to); ok {
+				return Decision{
+					Route:       rt.cfg.Name,
+					Reason:      reason,
+					AgentID:     rt.cfg.AgentID,
+					ModelName:   rt.cfg.ModelName,
+					Temperature: rt.cfg.Temperature,
+				}
+			}
+		}
+	}
+	return Decision{Route: DefaultRoute, Reason: "sem rota"}
+}
+
+func (rt route) match(phone, to string) (string, bool) {
+	if rt.phones[phone] {
+		return "allowlist", true
+	}
+	if to != "" && rt.to[to] {
+		return "destino " + to, true
+	}
+	if rt.cfg.Percent > 0 && phone != "" {
+		b := bucket(rt.cfg.Name, phone)
+		if float64(b) < rt.cfg.Percent*100 {
+			return fmt.Sprintf("%.4g%% (bucket %d)", rt.cfg.Percent, b), true
+		}
+	}
+	return "", false
+}
+
+// bucket distribui o telefone em 0..9999 de forma estável; o nome da rota
+// entra no hash para que rotas diferentes sorteiem grupos independentes.
+func bucket(name, phone string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(phone))
+	return h.Sum32() % 10000
+}
+
+func digitSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		if d := canonical(v); d != "" {
+			set[d] = true
+		}
+	}
+	return set
+}
+
+func canonical(s string) string {
+	if s == "" {
+		return ""
+	}
+	return phone.Canonical(s)
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// NewAdminHandler expõe a administração de sessões. Todas as rotas aceitam
+// ?tenant=... para identificar o número/tenant da sessão:
+//
+//	GET    /admin/sessions               lista sessões ativas
+//	GET    /admin/sessions/{phone}       consulta uma sessão
+//	DELETE /admin/sessions/{phone}       força a expiração
+//	POST   /admin/sessions/{phone}/reset limpa o conversationId
+//	POST   /admin/sessions/{phone}/mode  altera o modo ({"mode":"human","reason":"..."})
+//	POST   /admin/sessions/{phone}/release devolve a sessão para a IA
+func NewAdminHandler(mgr *Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/sessions"), "/")
+		if path == "" {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			writeJSON(w, http.StatusOK, mgr.List(NewKey(r.URL.Query().Get("tenant"), "").Tenant))
+			return
+		}
+
+		phone, action, _ := strings.Cut(path, "/")
+		key := NewKey(r.URL.Query().Get("tenant"), phone)
+
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			info, ok := mgr.Info(key)
+			if !ok {
+				http.Error(w, "sessao nao encontrada", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, info)
+
+		case action == "" && r.Method == http.MethodDelete:
+			if !mgr.Expire(key) {
+				http.Error(w, "sessao nao encontrada", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		case action == "reset" && r.Method == http.MethodPost:
+			if !mgr.ResetConversation(key) {
+				http.Error(w, "sessao nao encontrada", http.StatusNotFound)
+				return
+			}
+			info, _ := mgr.Info(key)
+			writeJSON(w, http.StatusOK, info)
+
+		case action == "mode" && r.Method == http.MethodPost:
+			var body struct {
+				Mode   string `json:"mode"`
+				Reason string `json:"reason"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "payload invalido", http.StatusBadRequest)
+				return
+			}
+			mode, ok := ParseMode(body.Mode)
+			if !ok {
+				http.Error(w, "modo invalido (ai, human, paused)", http.StatusBadRequest)
+				return
+			}
+			reason := body.Reason
+			if reason == "" {
+				reason = "admin"
+			}
+			writeJSON(w, http.StatusOK, mgr.SetMode(key, mode, reason))
+
+		case action == "release" && r.Method == http.MethodPost:
+			writeJSON(w, http.StatusOK, mgr.SetMode(key, ModeAI, "admin:release"))
+
+		case action == "" || action == "reset" || action == "mode" || action == "release":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -2,52 +2,142 @@ package session
 
 import (
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gothout/goqueue"
+
+	"whatsapp-ia-integrator/internal/phone"
+)
+
+// Mode define quem responde o contato.
+type Mode string
+
+const (
+	// ModeAI é o padrão: o Chatvolt responde.
+	ModeAI Mode = "ai"
+	// ModeHuman indica atendimento humano na CSA; a IA fica em silêncio.
+	ModeHuman Mode = "human"
+	// ModePaused silencia a IA sem transferir para humano.
+	ModePaused Mode = "paused"
 )
 
+// ParseMode valida um modo recebido de fora (API, metadata).
+func ParseMode(raw string) (Mode, bool) {
+	switch Mode(strings.ToLower(strings.TrimSpace(raw))) {
+	case ModeAI:
+		return ModeAI, true
+	case ModeHuman:
+		return ModeHuman, true
+	case ModePaused:
+		return ModePaused, true
+	}
+	return "", false
+}
+
+// Key identifica uma sessão: o mesmo cliente falando com dois números/tenants
+// tem duas sessões independentes. Tenant vazio equivale ao comportamento antigo.
+type Key struct {
+	Tenant string
+	Phone  string
+}
+
+// NewKey monta a chave de sessão com o telefone (e o tenant, quando é um número)
+// em E.164, para que formatos diferentes do mesmo número caiam na mesma sessão.
+func NewKey(tenant, phoneNumber string) Key {
+	if tenant = strings.TrimSpace(tenant); tenant != "" {
+		tenant = phone.Canonical(tenant)
+	}
+	return Key{Tenant: tenant, Phone: phone.Canonical(phoneNumber)}
+}
+
+// String retorna "tenant:phone" (ou só o telefone sem tenant), usada em filas e logs.
+func (k Key) String() string {
+	if k.Tenant == "" {
+		return k.Phone
+	}
+	return k.Tenant + ":" + k.Phone
+}
+
 // Session armazena dados de rastreamento da conversa.
 type Session struct {
-	Phone          string
+	Tenant string
+	Phone  string
+	// Channel e Destination são o canal e o telefone no formato recebido da
+	// CSA, usados nos envios.
+	Channel        string
+	Destination    string
 	Name           string
 	ConversationID string
 	VisitorID      string
+	Mode           Mode
+	ModeReason     string
 
-	lastActive time.Time
-	timer      *time.Timer
+	modeChangedAt time.Time
+	lastActive    time.Time
+	expiresAt     time.Time
+	timer         *time.Timer
 }
 
-// SessionEvent representa um estágio do ciclo de vida da sessão para logging.
+// SessionInfo é uma cópia da sessão para consulta administrativa.
+type SessionInfo struct {
+	Tenant              string    `json:"tenant,omitempty"`
+	Phone               string    `json:"phone"`
+	Channel             string    `json:"channel,omitempty"`
+	Destination         string    `json:"destination,omitempty"`
+	Name                string    `json:"name,omitempty"`
+	ConversationID      string    `json:"conversationId,omitempty"`
+	VisitorID           string    `json:"visitorId,omitempty"`
+	Mode                Mode      `json:"mode"`
+	ModeReason          string    `json:"modeReason,omitempty"`
+	ModeChangedAt       time.Time `json:"modeChangedAt"`
+	LastActive          time.Time `json:"lastActive"`
+	ExpiresAt           time.Time `json:"expiresAt"`
+	TTLRemainingSeconds int64     `json:"ttlRemainingSeconds"`
+}
+
+// SessionEvent representa um estágio do ciclo de vida da sessão, entregue aos sinks.
 type SessionEvent struct {
-	Phone        string
-	Name         string
-	Conversation string
-	Visitor      string
-	Stage        string
-	Timestamp    time.Time
+	Tenant       string    `json:"tenant,omitempty"`
+	Phone        string    `json:"phone"`
+	Name         string    `json:"name,omitempty"`
+	Conversation string    `json:"conversationId,omitempty"`
+	Visitor      string    `json:"visitorId,omitempty"`
+	Mode         Mode      `json:"mode,omitempty"`
+	Stage        string    `json:"stage"`
+	Timestamp    time.Time `json:"timestamp"`
 }
 
 // Manager controla sessões por número/ticket, expira após ttl.
 type Manager struct {
 	ttl time.Duration
+	// modeIdle devolve a sessão para a IA após esse tempo sem mensagens do contato.
+	modeIdle time.Duration
 
 	mu       sync.Mutex
-	sessions map[string]*Session
+	sessions map[Key]*Session
 
 	events   *goqueue.Queue[SessionEvent]
+	sinksMu  sync.RWMutex
+	sinks    []SessionEventSink
 	stopCh   chan struct{}
+	doneCh   chan struct{}
 	stopOnce sync.Once
 }
 
-// NewManager cria um gerenciador com ttl configurável.
-func NewManager(ttl time.Duration) *Manager {
+// NewManager cria um gerenciador com ttl configurável. modeIdle controla por quanto
+// tempo sem atividade uma sessão em modo humano/pausado permanece assim (0 = até liberar).
+func NewManager(ttl, modeIdle time.Duration) *Manager {
 	m := &Manager{
 		ttl:      ttl,
-		sessions: make(map[string]*Session),
+		modeIdle: modeIdle,
+		sessions: make(map[Key]*Session),
 		events:   goqueue.NewQueue[SessionEvent](0),
+		sinks:    []SessionEventSink{LogSink{}},
 		stopCh:   make(chan struct{}),
+		doneCh:   make(chan struct{}),
 	}
 
 	go m.processEvents()
@@ -55,107 +145,321 @@ func NewManager(ttl time.Duration) *Manager {
 	return m
 }
 
-// Upsert retorna a sessão do telefone e reseta o timer de expiração.
-func (m *Manager) Upsert(phone, name string) *Session {
+// Upsert retorna a sessão da chave e reseta o timer de expiração.
+func (m *Manager) Upsert(key Key, name string) *Session {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	s, ok := m.sessions[phone]
+	s, ok := m.sessions[key]
 	stage := "upsert:existing"
 	if !ok {
-		s = &Session{Phone: phone, Name: name}
-		m.sessions[phone] = s
+		s = &Session{Tenant: key.Tenant, Phone: key.Phone, Name: name, Mode: ModeAI}
+		m.sessions[key] = s
 		stage = "upsert:new"
 	}
 
+	now := time.Now()
+	if ok && s.Mode != ModeAI && m.modeIdle > 0 && now.Sub(s.lastActive) >= m.modeIdle {
+		m.setMode(s, ModeAI, "idle")
+	}
+
 	s.Name = name
-	s.lastActive = time.Now()
+	m.touch(s, now)
+	m.recordStage(s, stage)
+
+	return s
+}
+
+// SetMode altera o modo da sessão, criando-a se necessário.
+func (m *Manager) SetMode(key Key, mode Mode, reason string) SessionInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[key]
+	if !ok {
+		s = &Session{Tenant: key.Tenant, Phone: key.Phone, Mode: ModeAI}
+		m.sessions[key] = s
+		m.recordStage(s, "upsert:new")
+	}
+
+	now := time.Now()
+	m.setMode(s, mode, reason)
+	m.touch(s, now)
+
+	return s.info(now)
+}
+
+// SetDestination guarda o canal e o formato original do telefone para os
+// envios, criando a sessão se necessário.
+func (m *Manager) SetDestination(key Key, channel, destination string) {
+	destination = strings.TrimSpace(destination)
+	if destination == "" {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[key]
+	if !ok {
+		s = &Session{Tenant: key.Tenant, Phone: key.Phone, Mode: ModeAI}
+		m.sessions[key] = s
+		m.recordStage(s, "upsert:new")
+		m.touch(s, time.Now())
+	}
+	s.Destination = destination
+	if channel = strings.TrimSpace(channel); channel != "" {
+		s.Channel = channel
+	}
+}
+
+// Destination retorna o canal e o telefone no formato de envio; sem registro,
+// canal vazio (o padrão) e o telefone da chave.
+func (m *Manager) Destination(key Key) (channel, destination string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[key]; ok && s.Destination != "" {
+		return s.Channel, s.Destination
+	}
+	return "", key.Phone
+}
+
+// Mode retorna o modo atual da chave; sessões inexistentes estão em ModeAI.
+func (m *Manager) Mode(key Key) Mode {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[key]; ok {
+		return s.Mode
+	}
+	return ModeAI
+}
+
+func (m *Manager) setMode(s *Session, mode Mode, reason string) {
+	if s.Mode == mode {
+		return
+	}
+	s.Mode = mode
+	s.ModeReason = reason
+	s.modeChangedAt = time.Now()
+	m.recordStage(s, "mode:"+string(mode))
+}
+
+// touch renova a atividade e reprograma a expiração. Sessões fora do modo IA
+// vivem pelo menos modeIdle para não perder o atendimento humano.
+func (m *Manager) touch(s *Session, now time.Time) {
+	ttl := m.ttl
+	if s.Mode != ModeAI && m.modeIdle > ttl {
+		ttl = m.modeIdle
+	}
+
+	s.lastActive = now
+	s.expiresAt = now.Add(ttl)
 
 	if s.timer != nil {
 		s.timer.Stop()
 	}
-	s.timer = time.AfterFunc(m.ttl, func() {
-		m.expire(phone)
+	key := Key{Tenant: s.Tenant, Phone: s.Phone}
+	s.timer = time.AfterFunc(ttl, func() {
+		m.expire(key, false)
 	})
-
-	m.recordStage(s, stage)
-
-	return s
 }
 
 // UpdateConversation grava conversationId/visitorId após a resposta da IA.
-func (m *Manager) UpdateConversation(phone, conversationID, visitorID string) {
+func (m *Manager) UpdateConversation(key Key, conversationID, visitorID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if s, ok := m.sessions[phone]; ok {
+	if s, ok := m.sessions[key]; ok {
 		s.ConversationID = conversationID
 		s.VisitorID = visitorID
 		m.recordStage(s, "conversation:update")
 	}
 }
 
-// expire remove a sessão; chamado via timer.
-func (m *Manager) expire(phone string) {
+// expire remove a sessão; chamado via timer ou forçado pela administração.
+func (m *Manager) expire(key Key, force bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if s, ok := m.sessions[phone]; ok {
+	if s, ok := m.sessions[key]; ok {
+		if !force && time.Now().Before(s.expiresAt) {
+			// timer antigo disparou depois de um touch
+			return
+		}
 		if s.timer != nil {
 			s.timer.Stop()
 		}
 		m.recordStage(s, "expired")
-		delete(m.sessions, phone)
+		delete(m.sessions, key)
+	}
+}
+
+// ResetConversation limpa conversationId/visitorId para que a próxima mensagem
+// inicie uma nova conversa no Chatvolt.
+func (m *Manager) ResetConversation(key Key) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[key]
+	if !ok {
+		return false
 	}
+
+	s.ConversationID = ""
+	s.VisitorID = ""
+	m.recordStage(s, "conversation:reset")
+	return true
+}
+
+// Expire força a expiração da sessão. Retorna false se ela não existir.
+func (m *Manager) Expire(key Key) bool {
+	m.mu.Lock()
+	_, ok := m.sessions[key]
+	m.mu.Unlock()
+
+	if ok {
+		m.expire(key, true)
+	}
+	return ok
 }
 
 // Get retorna sessão sem alterar o timer.
-func (m *Manager) Get(phone string) (*Session, bool) {
+func (m *Manager) Get(key Key) (*Session, bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	s, ok := m.sessions[phone]
+	s, ok := m.sessions[key]
 	if ok {
 		m.recordStage(s, "get")
 	}
 	return s, ok
 }
 
-// Stop encerra o processamento de eventos de sessão.
+// Info retorna uma cópia da sessão sem alterar o timer.
+func (m *Manager) Info(key Key) (SessionInfo, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.sessions[key]
+	if !ok {
+		return SessionInfo{}, false
+	}
+	return s.info(time.Now()), true
+}
+
+// List retorna as sessões ativas ordenadas pela última atividade (mais recente primeiro).
+// Com tenant preenchido, retorna apenas as sessões daquele tenant.
+func (m *Manager) List(tenant string) []SessionInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	out := make([]SessionInfo, 0, len(m.sessions))
+	for key, s := range m.sessions {
+		if tenant != "" && key.Tenant != tenant {
+			continue
+		}
+		out = append(out, s.info(now))
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].LastActive.After(out[j].LastActive)
+	})
+	return out
+}
+
+// AddSink registra um destino adicional para os eventos de sessão.
+func (m *Manager) AddSink(sink SessionEventSink) {
+	m.sinksMu.Lock()
+	defer m.sinksMu.Unlock()
+	m.sinks = append(m.sinks, sink)
+}
+
+// Stop encerra o processamento de eventos de sessão, entrega os eventos
+// pendentes e fecha os sinks.
 func (m *Manager) Stop() {
 	m.stopOnce.Do(func() {
 		close(m.stopCh)
+		<-m.doneCh
+
+		m.sinksMu.RLock()
+		defer m.sinksMu.RUnlock()
+		for _, sink := range m.sinks {
+			if err := sink.Close(); err != nil {
+				log.Printf("[session] erro fechando sink: %v", err)
+			}
+		}
 	})
 }
 
+func (s *Session) info(now time.Time) SessionInfo {
+	remaining := s.expiresAt.Sub(now)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return SessionInfo{
+		Tenant:              s.Tenant,
+		Phone:               s.Phone,
+		Channel:             s.Channel,
+		Destination:         s.Destination,
+		Name:                s.Name,
+		ConversationID:      s.ConversationID,
+		VisitorID:           s.VisitorID,
+		Mode:                s.Mode,
+		ModeReason:          s.ModeReason,
+		ModeChangedAt:       s.modeChangedAt,
+		LastActive:          s.lastActive,
+		ExpiresAt:           s.expiresAt,
+		TTLRemainingSeconds: int64(remaining / time.Second),
+	}
+}
+
 func (m *Manager) recordStage(s *Session, stage string) {
 	if s == nil {
 		return
 	}
 
 	_ = m.events.Enqueue(SessionEvent{
+		Tenant:       s.Tenant,
 		Phone:        s.Phone,
 		Name:         s.Name,
 		Conversation: s.ConversationID,
 		Visitor:      s.VisitorID,
+		Mode:         s.Mode,
 		Stage:        stage,
 		Timestamp:    time.Now(),
 	})
 }
 
 func (m *Manager) processEvents() {
+	defer close(m.doneCh)
+
 	for {
+		if evt, ok := m.events.Dequeue(); ok {
+			m.dispatch(evt)
+			continue
+		}
+
 		select {
 		case <-m.stopCh:
 			return
 		default:
 		}
 
-		if evt, ok := m.events.Dequeue(); ok {
-			log.Printf("[session] stage=%s phone=%s name=%s conversation=%s visitor=%s at=%s", evt.Stage, evt.Phone, evt.Name, evt.Conversation, evt.Visitor, evt.Timestamp.Format(time.RFC3339))
-			continue
-		}
-
 		time.Sleep(25 * time.Millisecond)
 	}
 }
+
+func (m *Manager) dispatch(evt SessionEvent) {
+	m.sinksMu.RLock()
+	defer m.sinksMu.RUnlock()
+
+	for _, sink := range m.sinks {
+		if err := sink.Write(evt); err != nil {
+			log.Printf("[session] erro gravando evento stage=%s tenant=%s phone=%s: %v", evt.Stage, evt.Tenant, evt.Phone, err)
+		}
+	}
+}
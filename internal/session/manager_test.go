@@ -0,0 +1,18 @@
+package session
+
+import "testing"
+
+func TestNewKeyCanonicalizesTenant(t *testing.T) {
+	a := NewKey("+55 11 3333-4444", "5511988887777")
+	b := NewKey("551133334444", "+55 (11) 98888-7777")
+	if a != b {
+		t.Fatalf("mesmo tenant em formatos diferentes gerou chaves distintas: %v != %v", a, b)
+	}
+	if a.Tenant != "+551133334444" {
+		t.Errorf("Tenant = %q", a.Tenant)
+	}
+
+	if k := NewKey(" loja-bot ", "5511988887777"); k.Tenant != "loja-bot" {
+		t.Errorf("tenant que nao e numero deve ficar como veio: %q", k.Tenant)
+	}
+}
@@ -0,0 +1,23 @@
+package session
+
+import (
+	"log"
+	"time"
+)
+
+// SessionEventSink recebe os eventos de ciclo de vida das sessões.
+// Write é chamado por uma única goroutine, na ordem dos eventos.
+type SessionEventSink interface {
+	Write(evt SessionEvent) error
+	Close() error
+}
+
+// LogSink mantém o log textual histórico dos eventos.
+type LogSink struct{}
+
+func (LogSink) Write(evt SessionEvent) error {
+	log.Printf("[session] stage=%s tenant=%s phone=%s name=%s conversation=%s visitor=%s mode=%s at=%s", evt.Stage, evt.Tenant, evt.Phone, evt.Name, evt.Conversation, evt.Visitor, evt.Mode, evt.Timestamp.Format(time.RFC3339))
+	return nil
+}
+
+func (LogSink) Close() error { return nil }
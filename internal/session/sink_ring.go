@@ -0,0 +1,91 @@
+package session
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// RingSink mantém os últimos eventos em memória para consulta via API.
+type RingSink struct {
+	mu     sync.RWMutex
+	events []SessionEvent
+	next   int
+	full   bool
+}
+
+func NewRingSink(size int) *RingSink {
+	if size <= 0 {
+		size = 1000
+	}
+	return &RingSink{events: make([]SessionEvent, size)}
+}
+
+func (s *RingSink) Write(evt SessionEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events[s.next] = evt
+	s.next = (s.next + 1) % len(s.events)
+	if s.next == 0 {
+		s.full = true
+	}
+	return nil
+}
+
+func (s *RingSink) Close() error { return nil }
+
+// Recent retorna até limit eventos, do mais recente para o mais antigo,
+// opcionalmente filtrando por tenant e telefone.
+func (s *RingSink) Recent(limit int, tenant, phone string) []SessionEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := s.next
+	if s.full {
+		count = len(s.events)
+	}
+	if limit <= 0 || limit > count {
+		limit = count
+	}
+
+	out := make([]SessionEvent, 0, limit)
+	for i := 0; i < count && len(out) < limit; i++ {
+		idx := (s.next - 1 - i + len(s.events)) % len(s.events)
+		evt := s.events[idx]
+		if tenant != "" && evt.Tenant != tenant {
+			continue
+		}
+		if phone != "" && evt.Phone != phone {
+			continue
+		}
+		out = append(out, evt)
+	}
+	return out
+}
+
+// NewEventsHandler expõe os eventos do RingSink:
+//
+//	GET /admin/session-events?tenant=...&phone=...&limit=100
+func NewEventsHandler(ring *RingSink) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		limit := 100
+		if raw := r.URL.Query().Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 0 {
+				http.Error(w, "limit invalido", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		// filtros no mesmo formato das chaves de sessão
+		filter := NewKey(r.URL.Query().Get("tenant"), r.URL.Query().Get("phone"))
+		writeJSON(w, http.StatusOK, ring.Recent(limit, filter.Tenant, filter.Phone))
+	})
+}
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WebhookSink envia eventos em lotes (array JSON) via POST para uma URL externa.
+// Um lote é enviado ao atingir batchSize ou a cada flushInterval.
+type WebhookSink struct {
+	url           string
+	headers       map[string]string
+	batchSize     int
+	flushInterval time.Duration
+	maxBuffered   int
+	httpClient    *http.Client
+
+	mu      sync.Mutex
+	buffer  []SessionEvent
+	dropped int
+
+	flushCh  chan struct{}
+	stopCh   chan struct{}
+	doneCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewWebhookSink cria o sink e inicia o envio periódico.
+func NewWebhookSink(url string, headers map[string]string, batchSize int, flushInterval time.Duration) *WebhookSink {
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+	if flushInterval <= 0 {
+		flushInterval = 5 * time.Second
+	}
+
+	s := &WebhookSink{
+		url:           url,
+		headers:       headers,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		maxBuffered:   batchSize * 20,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		flushCh:       make(chan struct{}, 1),
+		stopCh:        make(chan struct{}),
+		doneCh:        make(chan struct{}),
+	}
+
+	go s.loop()
+
+	return s
+}
+
+func (s *WebhookSink) Write(evt SessionEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.buffer) >= s.maxBuffered {
+		// destino fora do ar: descarta o mais antigo para não crescer sem limite
+		s.buffer = s.buffer[1:]
+		s.dropped++
+	}
+	s.buffer = append(s.buffer, evt)
+
+	if len(s.buffer) >= s.batchSize {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close envia o que restou no buffer e encerra o loop.
+func (s *WebhookSink) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	<-s.doneCh
+	return nil
+}
+
+func (s *WebhookSink) loop() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			s.flush()
+			return
+		case <-ticker.C:
+			s.flush()
+		case <-s.flushCh:
+			s.flush()
+		}
+	}
+}
+
+func (s *WebhookSink) flush() {
+	for {
+		s.mu.Lock()
+		n := len(s.buffer)
+		if n == 0 {
+			s.mu.Unlock()
+			return
+		}
+		if n > s.batchSize {
+			n = s.batchSize
+		}
+		batch := make([]SessionEvent, n)
+		copy(batch, s.buffer[:n])
+		s.dropped = 0
+		s.mu.Unlock()
+
+		if err := s.send(batch); err != nil {
+			log.Printf("[session-webhook] erro enviando %d eventos: %v", len(batch), err)
+			return
+		}
+
+		s.mu.Lock()
+		// itens do lote podem ter sido descartados do buffer durante o envio
+		if sent := n - s.dropped; sent > 0 {
+			s.buffer = s.buffer[sent:]
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *WebhookSink) send(batch []SessionEvent) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("marshal session events: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("new session webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("call session webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("session webhook error: status=%d", resp.StatusCode)
+	}
+	return nil
+}
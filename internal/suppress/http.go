@@ -0,0 +1,76 @@
+package suppress
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// NewAdminHandler expõe a lista de supressão:
+//
+//	GET    /admin/suppressions          lista os contatos suprimidos
+//	GET    /admin/suppressions/{phone}  consulta um contato
+//	PUT    /admin/suppressions/{phone}  inclui ({"reason":"blocked","note":"..."})
+//	DELETE /admin/suppressions/{phone}  remove
+func NewAdminHandler(list *List) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		phone := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/suppressions"), "/")
+		if phone == "" {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			writeJSON(w, http.StatusOK, list.Entries())
+			return
+		}
+		if key(phone) == "" {
+			http.Error(w, "telefone invalido", http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			e, ok := list.Get(phone)
+			if !ok {
+				http.Error(w, "contato nao suprimido", http.StatusNotFound)
+				return
+			}
+			writeJSON(w, http.StatusOK, e)
+
+		case http.MethodPut, http.MethodPost:
+			var body struct {
+				Reason string `json:"reason"`
+				Note   string `json:"note"`
+			}
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					http.Error(w, "payload invalido", http.StatusBadRequest)
+					return
+				}
+			}
+			switch body.Reason {
+			case "", ReasonBlocked, ReasonOptOut:
+			default:
+				http.Error(w, "motivo invalido (blocked, optout)", http.StatusBadRequest)
+				return
+			}
+			writeJSON(w, http.StatusOK, list.Add(phone, body.Reason, body.Note))
+
+		case http.MethodDelete:
+			if !list.Remove(phone) {
+				http.Error(w, "contato nao suprimido", http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,225 @@
+package suppress
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"whatsapp-ia-integrator/internal/phone"
+)
+
+// motivos de supressão
+const (
+	// ReasonOptOut: o próprio cliente pediu para não receber mensagens.
+	ReasonOptOut = "optout"
+	// ReasonBlocked: número bloqueado pela operação (ex.: abuso).
+	ReasonBlocked = "blocked"
+)
+
+// Entry é um contato que não deve receber mensagens.
+type Entry struct {
+	Phone     string    `json:"phone"`
+	Reason    string    `json:"reason"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// op é uma linha do arquivo de persistência.
+type op struct {
+	Op    string `json:"op"` // "add" ou "remove"
+	Entry Entry  `json:"entry"`
+}
+
+// List guarda a lista de supressão. Com path preenchido, as alterações são
+// gravadas em JSONL (append) e o arquivo é compactado na abertura.
+// As leituras (Get, Suppressed, Entries) e Close aceitam receiver nil (lista
+// vazia); Add e Remove exigem a lista criada por NewList.
+type List struct {
+	mu      sync.RWMutex
+	entries map[string]Entry
+	file    *os.File
+}
+
+// NewList abre a lista; path vazio mantém tudo só em memória.
+func NewList(path string) (*List, error) {
+	l := &List{entries: make(map[string]Entry)}
+	if path == "" {
+		return l, nil
+	}
+
+	if err := l.load(path); err != nil {
+		return nil, err
+	}
+	if err := l.compact(path); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Add inclui ou atualiza o contato.
+func (l *List) Add(phone, reason, note string) Entry {
+	e := Entry{Phone: key(phone), Reason: reason, Note: note, CreatedAt: time.Now().UTC()}
+	if e.Reason == "" {
+		e.Reason = ReasonBlocked
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[e.Phone] = e
+	l.persist(op{Op: "add", Entry: e})
+	return e
+}
+
+// Remove tira o contato da lista. Retorna false se ele não estava nela.
+func (l *List) Remove(phone string) bool {
+	phone = key(phone)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[phone]
+	if !ok {
+		return false
+	}
+	delete(l.entries, phone)
+	l.persist(op{Op: "remove", Entry: e})
+	return true
+}
+
+// Get retorna a entrada do contato, se houver.
+func (l *List) Get(phone string) (Entry, bool) {
+	if l == nil {
+		return Entry{}, false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	e, ok := l.entries[key(phone)]
+	return e, ok
+}
+
+// Suppressed informa se o contato não deve receber mensagens.
+func (l *List) Suppressed(phone string) bool {
+	_, ok := l.Get(phone)
+	return ok
+}
+
+// Entries lista os contatos suprimidos, mais recentes primeiro.
+func (l *List) Entries() []Entry {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.RLock()
+	out := make([]Entry, 0, len(l.entries))
+	for _, e := range l.entries {
+		out = append(out, e)
+	}
+	l.mu.RUnlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Close fecha o arquivo de persistência.
+func (l *List) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+func (l *List) persist(o op) {
+	if l.file == nil {
+		return
+	}
+
+	line, err := json.Marshal(o)
+	if err != nil {
+		return
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		log.Printf("[suppress] erro gravando %s de %s: %v", o.Op, o.Entry.Phone, err)
+	}
+}
+
+func (l *List) load(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open suppression file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var o op
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil || o.Entry.Phone == "" {
+			continue
+		}
+		switch o.Op {
+		case "add":
+			l.entries[o.Entry.Phone] = o.Entry
+		case "remove":
+			delete(l.entries, o.Entry.Phone)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read suppression file: %w", err)
+	}
+	return nil
+}
+
+// compact reescreve o arquivo só com as entradas vigentes e o mantém aberto para append.
+func (l *List) compact(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create suppression file: %w", err)
+	}
+
+	w := bufio.NewWriter(f)
+	for _, e := range l.entries {
+		line, _ := json.Marshal(op{Op: "add", Entry: e})
+		_, _ = w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write suppression file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close suppression file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("rename suppression file: %w", err)
+	}
+
+	l.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open suppression file: %w", err)
+	}
+	return nil
+}
+
+// key compara telefones pelo E.164.
+func key(phoneNumber string) string {
+	return phone.Canonical(phoneNumber)
+}
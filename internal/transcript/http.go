@@ -0,0 +1,112 @@
+package transcript
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsapp-ia-integrator/internal/phone"
+)
+
+// NewHandler expõe a transcrição de um telefone:
+//
+//	GET /admin/transcripts/{phone}?conversation=...&from=...&to=...&format=json|csv|txt
+//
+// from/to aceitam RFC3339 ou AAAA-MM-DD (o dia de "to" é incluído por inteiro).
+func NewHandler(store *Store) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		number := strings.Trim(strings.TrimPrefix(r.URL.Path, "/admin/transcripts"), "/")
+		if number == "" {
+			http.Error(w, "telefone requerido", http.StatusBadRequest)
+			return
+		}
+
+		q := r.URL.Query()
+		from, err := parseBound(q.Get("from"), false)
+		if err != nil {
+			http.Error(w, "from invalido", http.StatusBadRequest)
+			return
+		}
+		to, err := parseBound(q.Get("to"), true)
+		if err != nil {
+			http.Error(w, "to invalido", http.StatusBadRequest)
+			return
+		}
+
+		entries := store.Query(phone.Canonical(number), q.Get("conversation"), from, to)
+
+		switch strings.ToLower(q.Get("format")) {
+		case "", "json":
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(entries)
+		case "csv":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "transcript-"+number+".csv"))
+			writeCSV(w, entries)
+		case "txt", "text":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			writeText(w, entries)
+		default:
+			http.Error(w, "format invalido (json, csv, txt)", http.StatusBadRequest)
+		}
+	})
+}
+
+func parseBound(raw string, endOfDay bool) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func writeCSV(w http.ResponseWriter, entries []Entry) {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"timestamp", "direction", "phone", "conversation_id", "message_id", "status", "updated_at", "text"})
+	for _, e := range entries {
+		_ = cw.Write([]string{
+			e.Timestamp.Format(time.RFC3339),
+			string(e.Direction),
+			e.Phone,
+			e.ConversationID,
+			e.MessageID,
+			e.Status,
+			e.UpdatedAt.Format(time.RFC3339),
+			e.Text,
+		})
+	}
+	cw.Flush()
+}
+
+func writeText(w http.ResponseWriter, entries []Entry) {
+	for _, e := range entries {
+		who := "cliente"
+		if e.Direction == DirectionOutbound {
+			who = "bot"
+		}
+
+		status := ""
+		if e.Status != "" {
+			status = " (" + e.Status + ")"
+		}
+
+		fmt.Fprintf(w, "[%s] %s%s: %s\n", e.Timestamp.Format("2006-01-02 15:04:05"), who, status, strings.ReplaceAll(e.Text, "\n", "\n    "))
+	}
+}
@@ -0,0 +1,349 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Direction indica se a mensagem veio do cliente ou foi enviada pelo integrador.
+type Direction string
+
+const (
+	DirectionInbound  Direction = "inbound"
+	DirectionOutbound Direction = "outbound"
+)
+
+// Entry é uma mensagem registrada na transcrição.
+type Entry struct {
+	ID             int64     `json:"id"`
+	Phone          string    `json:"phone"`
+	ConversationID string    `json:"conversationId,omitempty"`
+	Direction      Direction `json:"direction"`
+	MessageID      string    `json:"messageId,omitempty"`
+	Text           string    `json:"text"`
+	Status         string    `json:"status,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// record é a linha gravada no arquivo de persistência (append-only).
+type record struct {
+	Op             string    `json:"op"`
+	Entry          *Entry    `json:"entry,omitempty"`
+	ID             int64     `json:"id,omitempty"`
+	MessageID      string    `json:"messageId,omitempty"`
+	ConversationID string    `json:"conversationId,omitempty"`
+	Status         string    `json:"status,omitempty"`
+	At             time.Time `json:"at,omitempty"`
+}
+
+// minCompact é o mínimo de linhas gravadas antes de compactar o arquivo.
+const minCompact = 1000
+
+// Store mantém as transcrições por telefone, com persistência opcional em JSONL.
+type Store struct {
+	maxPerPhone int
+
+	mu        sync.RWMutex
+	seq       int64
+	byPhone   map[string][]*Entry
+	byID      map[int64]*Entry
+	byMessage map[string]*Entry
+	path      string
+	file      *os.File
+	// written conta as linhas gravadas desde a última compactação.
+	written int
+}
+
+// NewStore cria o store. Com path preenchido, carrega o histórico do arquivo e
+// passa a gravar cada alteração nele. maxPerPhone <= 0 significa sem limite.
+// O arquivo é compactado na abertura e sempre que as linhas gravadas passam do
+// número de entradas em memória: guarda só o que maxPerPhone mantém.
+func NewStore(path string, maxPerPhone int) (*Store, error) {
+	s := &Store{
+		maxPerPhone: maxPerPhone,
+		byPhone:     make(map[string][]*Entry),
+		byID:        make(map[int64]*Entry),
+		byMessage:   make(map[string]*Entry),
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	s.path = path
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// RecordInbound registra uma mensagem do cliente e retorna o id interno da entrada.
+func (s *Store) RecordInbound(phone, conversationID, messageID, text string, at time.Time) int64 {
+	return s.add(&Entry{
+		Phone:          phone,
+		ConversationID: conversationID,
+		Direction:      DirectionInbound,
+		MessageID:      messageID,
+		Text:           text,
+		Status:         "received",
+		Timestamp:      at,
+	})
+}
+
+// RecordOutbound registra uma mensagem enviada (ou que falhou) para o cliente.
+func (s *Store) RecordOutbound(phone, conversationID, messageID, text, status string) int64 {
+	return s.add(&Entry{
+		Phone:          phone,
+		ConversationID: conversationID,
+		Direction:      DirectionOutbound,
+		MessageID:      messageID,
+		Text:           text,
+		Status:         strings.ToLower(status),
+		Timestamp:      time.Now().UTC(),
+	})
+}
+
+// SetConversation associa entradas ainda sem conversa (ex.: a primeira mensagem
+// antes da resposta da IA) ao conversationId definitivo.
+func (s *Store) SetConversation(conversationID string, ids ...int64) {
+	if conversationID == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		e, ok := s.byID[id]
+		if !ok || e.ConversationID != "" {
+			continue
+		}
+		e.ConversationID = conversationID
+		s.persist(record{Op: "conversation", ID: id, ConversationID: conversationID})
+	}
+}
+
+// UpdateStatus atualiza o status de entrega pelo messageId da CSA.
+func (s *Store) UpdateStatus(messageID, status string) bool {
+	if messageID == "" || status == "" {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.byMessage[messageID]
+	if !ok {
+		return false
+	}
+
+	now := time.Now().UTC()
+	e.Status = strings.ToLower(status)
+	e.UpdatedAt = now
+	s.persist(record{Op: "status", MessageID: messageID, Status: e.Status, At: now})
+	return true
+}
+
+// Query retorna as entradas do telefone em ordem cronológica, filtrando por
+// conversa e intervalo [from, to). Datas zeradas não filtram.
+func (s *Store) Query(phone, conversationID string, from, to time.Time) []Entry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]Entry, 0)
+	for _, e := range s.byPhone[phone] {
+		if conversationID != "" && e.ConversationID != conversationID {
+			continue
+		}
+		if !from.IsZero() && e.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !e.Timestamp.Before(to) {
+			continue
+		}
+		out = append(out, *e)
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+	return out
+}
+
+// Close fecha o arquivo de persistência.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+func (s *Store) add(e *Entry) int64 {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now().UTC()
+	}
+	e.UpdatedAt = e.Timestamp
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	e.ID = s.seq
+	s.index(e)
+	s.persist(record{Op: "entry", Entry: e})
+	return e.ID
+}
+
+func (s *Store) index(e *Entry) {
+	entries := append(s.byPhone[e.Phone], e)
+	if s.maxPerPhone > 0 && len(entries) > s.maxPerPhone {
+		for _, old := range entries[:len(entries)-s.maxPerPhone] {
+			delete(s.byID, old.ID)
+			if old.MessageID != "" && s.byMessage[old.MessageID] == old {
+				delete(s.byMessage, old.MessageID)
+			}
+		}
+		entries = append([]*Entry(nil), entries[len(entries)-s.maxPerPhone:]...)
+	}
+	s.byPhone[e.Phone] = entries
+
+	s.byID[e.ID] = e
+	if e.MessageID != "" {
+		s.byMessage[e.MessageID] = e
+	}
+}
+
+// persist grava o registro no arquivo; deve ser chamado com s.mu travado.
+func (s *Store) persist(r record) {
+	if s.file == nil {
+		return
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		log.Printf("[transcript] erro serializando registro: %v", err)
+		return
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		log.Printf("[transcript] erro gravando registro: %v", err)
+	}
+
+	// status, conversas e entradas descartadas por maxPerPhone só crescem o
+	// arquivo: compacta quando ele passa do dobro das entradas vivas
+	s.written++
+	if s.written > minCompact && s.written > len(s.byID) {
+		if err := s.compact(); err != nil {
+			log.Printf("[transcript] erro compactando arquivo: %v", err)
+		}
+	}
+}
+
+// load reaplica o arquivo de persistência.
+func (s *Store) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open transcript file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			log.Printf("[transcript] linha invalida ignorada: %v", err)
+			continue
+		}
+
+		switch r.Op {
+		case "entry":
+			if r.Entry == nil {
+				continue
+			}
+			if r.Entry.ID > s.seq {
+				s.seq = r.Entry.ID
+			}
+			s.index(r.Entry)
+		case "conversation":
+			if e, ok := s.byID[r.ID]; ok {
+				e.ConversationID = r.ConversationID
+			}
+		case "status":
+			if e, ok := s.byMessage[r.MessageID]; ok {
+				e.Status = r.Status
+				e.UpdatedAt = r.At
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read transcript file: %w", err)
+	}
+	return nil
+}
+
+// compact reescreve o arquivo só com as entradas em memória, já com conversa e
+// status aplicados, e o mantém aberto para append. Deve ser chamado com s.mu travado.
+func (s *Store) compact() error {
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("create transcript file: %w", err)
+	}
+
+	entries := make([]*Entry, 0, len(s.byID))
+	for _, e := range s.byID {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+
+	w := bufio.NewWriter(f)
+	for _, e := range entries {
+		line, err := json.Marshal(record{Op: "entry", Entry: e})
+		if err != nil {
+			continue
+		}
+		_, _ = w.Write(append(line, '\n'))
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("write transcript file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close transcript file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("rename transcript file: %w", err)
+	}
+	// o descritor antigo aponta para o arquivo substituído
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open transcript file: %w", err)
+	}
+	s.written = 0
+	return nil
+}
@@ -0,0 +1,72 @@
+package transcript
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStoreCompactsOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcripts.jsonl")
+
+	s, err := NewStore(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, text := range []string{"um", "dois", "tres", "quatro"} {
+		s.RecordOutbound("+5511987654321", "", "m"+text, text, "submitted")
+		s.SetConversation("c1", int64(i+1))
+	}
+	s.UpdateStatus("mquatro", "delivered")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = NewStore(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	got := s.Query("+5511987654321", "", time.Time{}, time.Time{})
+	if len(got) != 2 || got[0].Text != "tres" || got[1].Status != "delivered" || got[1].ConversationID != "c1" {
+		t.Fatalf("entradas depois de reabrir = %+v", got)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(raw, []byte("\n")); lines != 2 {
+		t.Errorf("arquivo com %d linhas, want 2 depois de compactar", lines)
+	}
+
+	// novas entradas continuam numeradas depois das existentes
+	if id := s.RecordInbound("+5511987654321", "c1", "m5", "cinco", time.Now()); id != 5 {
+		t.Errorf("id = %d, want 5", id)
+	}
+}
+
+func TestStoreCompactsWhileRunning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transcripts.jsonl")
+
+	s, err := NewStore(path, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 3*minCompact; i++ {
+		s.RecordInbound("+5511987654321", "", "", "oi", time.Now())
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(raw, []byte("\n")); lines > minCompact+1 {
+		t.Errorf("arquivo com %d linhas, want no maximo %d", lines, minCompact+1)
+	}
+}
@@ -0,0 +1,215 @@
+package whatsapp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/dedup"
+)
+
+// maxWebhookBody limita o corpo lido para verificar a assinatura.
+const maxWebhookBody = 1 << 20
+
+// authRejected conta webhooks recusados por motivo (exposto em /debug/vars).
+var authRejected = expvar.NewMap("webhook_auth_rejected")
+
+// Verifier autentica chamadas ao webhook: allowlist de IP, secret compartilhado
+// e assinatura HMAC com proteção contra replay.
+type Verifier struct {
+	cfg     config.WebhookAuthConfig
+	allowed []*net.IPNet
+	replay  *dedup.Store
+}
+
+// NewVerifier valida a configuração (CIDRs) e prepara o cache anti-replay.
+func NewVerifier(cfg config.WebhookAuthConfig) (*Verifier, error) {
+	v := &Verifier{cfg: cfg}
+
+	for _, raw := range cfg.AllowedIPs {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if !strings.Contains(raw, "/") {
+			if strings.Contains(raw, ":") {
+				raw += "/128"
+			} else {
+				raw += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(raw)
+		if err != nil {
+			return nil, fmt.Errorf("allowed_ips invalido %q: %w", raw, err)
+		}
+		v.allowed = append(v.allowed, network)
+	}
+
+	if cfg.HMACSecret != "" {
+		// assinaturas são únicas dentro da janela de tolerância do timestamp
+		replay, err := dedup.NewStore(2*cfg.MaxSkew(), 0, "")
+		if err != nil {
+			return nil, err
+		}
+		v.replay = replay
+	}
+
+	return v, nil
+}
+
+// Enabled indica se algum mecanismo de verificação está configurado.
+func (v *Verifier) Enabled() bool {
+	return len(v.allowed) > 0 || v.cfg.Secret != "" || v.cfg.HMACSecret != ""
+}
+
+// Wrap protege o handler. Falhas de credencial retornam 401; origem fora do allowlist, 403.
+// A assinatura HMAC não se aplica ao GET de verificação (sem corpo) nem às rotas
+// de provedores que assinam com esquema próprio (ex.: /whatsapp/webhook/meta),
+// validadas pelo adapter.
+func (v *Verifier) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(v.allowed) > 0 && !v.ipAllowed(r) {
+			v.reject(w, r, http.StatusForbidden, "ip_not_allowed", "origem nao permitida")
+			return
+		}
+
+		if v.cfg.Secret != "" && !v.secretValid(r) {
+			v.reject(w, r, http.StatusUnauthorized, "invalid_secret", "token invalido")
+			return
+		}
+
+		if v.cfg.HMACSecret != "" && r.Method == http.MethodPost && !selfSigned(r.URL.Path) {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+			if err != nil {
+				http.Error(w, "erro lendo payload", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			if reason, msg := v.checkSignature(r, body); reason != "" {
+				v.reject(w, r, http.StatusUnauthorized, reason, msg)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// selfSigned indica rotas de provedores com assinatura própria.
+func selfSigned(path string) bool {
+	return strings.EqualFold(providerFromPath(path), "meta")
+}
+
+func (v *Verifier) reject(w http.ResponseWriter, r *http.Request, status int, reason, msg string) {
+	authRejected.Add(reason, 1)
+	log.Printf("[webhook-auth] requisicao recusada de %s: %s", r.RemoteAddr, reason)
+	http.Error(w, msg, status)
+}
+
+func (v *Verifier) secretValid(r *http.Request) bool {
+	provided := r.Header.Get(v.cfg.SecretHeader)
+	if provided == "" {
+		provided = r.URL.Query().Get(v.cfg.SecretQuery)
+	}
+	return provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(v.cfg.Secret)) == 1
+}
+
+// checkSignature valida HMAC-SHA256(hmac_secret, "<timestamp>.<body>") em hex,
+// aceitando o prefixo "sha256=". Retorna o motivo da recusa ou "".
+func (v *Verifier) checkSignature(r *http.Request, body []byte) (string, string) {
+	rawTS := strings.TrimSpace(r.Header.Get(v.cfg.TimestampHeader))
+	ts, err := strconv.ParseInt(rawTS, 10, 64)
+	if err != nil {
+		return "missing_timestamp", "timestamp ausente ou invalido"
+	}
+	if ts > 1e12 {
+		ts /= 1000
+	}
+	skew := time.Since(time.Unix(ts, 0))
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > v.cfg.MaxSkew() {
+		return "stale_timestamp", "timestamp fora da janela permitida"
+	}
+
+	signature := strings.TrimPrefix(strings.TrimSpace(r.Header.Get(v.cfg.SignatureHeader)), "sha256=")
+	provided, err := hex.DecodeString(signature)
+	if err != nil || len(provided) == 0 {
+		return "missing_signature", "assinatura ausente ou invalida"
+	}
+
+	mac := hmac.New(sha256.New, []byte(v.cfg.HMACSecret))
+	mac.Write([]byte(rawTS))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	if !hmac.Equal(provided, mac.Sum(nil)) {
+		return "invalid_signature", "assinatura invalida"
+	}
+
+	// chave pelos bytes decodificados: a mesma assinatura em outra caixa de hex
+	// também é repetição
+	if v.replay.Seen(hex.EncodeToString(provided)) {
+		return "replayed_signature", "requisicao repetida"
+	}
+
+	return "", ""
+}
+
+func (v *Verifier) ipAllowed(r *http.Request) bool {
+	ip := net.ParseIP(clientIP(r, v.cfg.TrustForwardedFor, v.cfg.TrustedHops))
+	if ip == nil {
+		return false
+	}
+	for _, network := range v.allowed {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP retorna o endereço de origem. Atrás de proxy, cada um acrescenta à
+// direita do X-Forwarded-For quem o chamou; só as últimas trustedHops entradas
+// são confiáveis, e o que vem antes delas é controlado pelo cliente.
+func clientIP(r *http.Request, trustForwarded bool, trustedHops int) string {
+	if trustForwarded {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(header, ",") {
+				if hop = strings.TrimSpace(hop); hop != "" {
+					hops = append(hops, hop)
+				}
+			}
+		}
+		if len(hops) > 0 {
+			if trustedHops < 1 {
+				trustedHops = 1
+			}
+			i := len(hops) - trustedHops
+			if i < 0 {
+				i = 0
+			}
+			return hops[i]
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,102 @@
+package whatsapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"whatsapp-ia-integrator/internal/config"
+)
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		forward []string
+		trust   bool
+		hops    int
+		want    string
+	}{
+		{"sem proxy", nil, false, 1, "10.0.0.9"},
+		{"header ignorado sem trust", []string{"203.0.113.7"}, false, 1, "10.0.0.9"},
+		{"um proxy", []string{"203.0.113.7"}, true, 1, "203.0.113.7"},
+		{"cliente forja a esquerda", []string{"198.51.100.1, 203.0.113.7"}, true, 1, "203.0.113.7"},
+		{"dois proxies", []string{"198.51.100.1, 203.0.113.7, 10.1.1.1"}, true, 2, "203.0.113.7"},
+		{"headers repetidos", []string{"198.51.100.1", "203.0.113.7"}, true, 1, "203.0.113.7"},
+		{"menos entradas que hops", []string{"203.0.113.7"}, true, 3, "203.0.113.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/whatsapp/webhook", nil)
+			r.RemoteAddr = "10.0.0.9:51234"
+			for _, v := range tt.forward {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := clientIP(r, tt.trust, tt.hops); got != tt.want {
+				t.Errorf("clientIP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifierRejectsSpoofedForwardedFor(t *testing.T) {
+	v, err := NewVerifier(config.WebhookAuthConfig{AllowedIPs: []string{"198.51.100.1"}, TrustForwardedFor: true, TrustedHops: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := v.Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+
+	r := httptest.NewRequest(http.MethodPost, "/whatsapp/webhook", nil)
+	r.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("IP forjado = %d, want 403", w.Code)
+	}
+
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("IP permitido = %d, want 200", w.Code)
+	}
+}
+
+func TestVerifierRejectsReplayedSignature(t *testing.T) {
+	cfg := config.WebhookAuthConfig{HMACSecret: "segredo", SignatureHeader: "X-Signature", TimestampHeader: "X-Timestamp", MaxSkewSeconds: 300}
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := v.Wrap(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+
+	body := `{"event":"message","messageId":"m1"}`
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(cfg.HMACSecret))
+	mac.Write([]byte(ts + "." + body))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	for _, tt := range []struct {
+		signature string
+		want      int
+	}{
+		{"sha256=" + signature, http.StatusOK},
+		{"sha256=" + signature, http.StatusUnauthorized},
+		{strings.ToUpper(signature), http.StatusUnauthorized},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/whatsapp/webhook", strings.NewReader(body))
+		r.Header.Set("X-Timestamp", ts)
+		r.Header.Set("X-Signature", tt.signature)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("assinatura %s = %d, want %d", tt.signature, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"whatsapp-ia-integrator/internal/chatvolt"
+	"whatsapp-ia-integrator/internal/queue"
+	"whatsapp-ia-integrator/internal/session"
+)
+
+// CallbackPath é o endpoint que recebe as respostas assíncronas do Chatvolt.
+const CallbackPath = "/chatvolt/callback"
+
+// ações quando o callback não chega a tempo
+const (
+	callbackRetry   = "retry"
+	callbackApology = "apology"
+)
+
+// pendingCallback é uma consulta enviada em modo assíncrono aguardando a resposta.
+type pendingCallback struct {
+	msg            inboundMessage
+	conversationID string
+	req            chatvolt.QueryRequest
+	notified       bool
+	timer          *time.Timer
+	// held guarda as mensagens da sessão que chegaram enquanto a resposta não
+	// veio; seguem para a IA depois dela, com o conversationId atualizado.
+	held []inboundMessage
+}
+
+// queryAsync envia a consulta com a URL de callback e retorna sem esperar a
+// resposta. O id no callbackURL é aleatório e funciona como credencial da
+// resposta. Até a consulta ser resolvida (resposta, timeout ou /reset), as
+// mensagens seguintes da sessão ficam retidas nela. Deve rodar dentro da mailbox.
+func (h *Handler) queryAsync(ctx context.Context, msg inboundMessage, conversationID string, req chatvolt.QueryRequest, notified bool) error {
+	id, err := newCallbackID()
+	if err != nil {
+		return fmt.Errorf("gerando id do callback: %w", err)
+	}
+	req.CallbackURL = strings.TrimRight(h.callback.PublicURL, "/") + CallbackPath + "?id=" + url.QueryEscape(id)
+
+	// registrado antes do envio: o callback pode chegar antes do retorno da chamada
+	p := &pendingCallback{msg: msg, conversationID: conversationID, req: req, notified: notified}
+	h.callbackMu.Lock()
+	h.callbacks[id] = p
+	p.timer = time.AfterFunc(h.callback.Timeout(), func() { h.callbackTimeout(id) })
+	h.callbackMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 25*time.Second)
+	defer cancel()
+
+	if err := h.chatvolt.QueryAsync(ctx, req); err != nil {
+		if h.takeCallback(id) != nil {
+			req.CallbackURL = ""
+			h.onQueryError(msg, conversationID, req, notified, err)
+		}
+		return err
+	}
+
+	log.Printf("[webhook] consulta de %s enviada em modo callback (%s)", msg.Key, id)
+	return nil
+}
+
+// ServeCallback recebe a resposta do Chatvolt e a entrega na sessão que fez a consulta.
+func (h *Handler) ServeCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "erro lendo corpo", http.StatusBadRequest)
+		return
+	}
+	var resp chatvolt.QueryResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	key, ok := h.callbackKey(id)
+	if !ok {
+		log.Printf("[webhook] callback %q desconhecido ou expirado", id)
+		http.Error(w, "callback desconhecido ou expirado", http.StatusGone)
+		return
+	}
+
+	h.mailbox.Submit(key.String(), func() {
+		// retirada dentro da mailbox: nenhuma mensagem da sessão passa à frente
+		// da resposta; nil se expirou ou houve /reset enquanto aguardava a vez
+		p := h.takeCallback(id)
+		if p == nil {
+			return
+		}
+		defer h.resumeHeld(p)
+
+		if resp.Answer == "" && len(resp.Metadata) == 0 {
+			req := p.req
+			req.CallbackURL = ""
+			h.onQueryError(p.msg, p.conversationID, req, p.notified, fmt.Errorf("callback %s sem resposta", id))
+			return
+		}
+		log.Printf("[webhook] resposta da IA recebida por callback para %s (%s)", key, id)
+		h.deliverAnswer(p.msg, &resp)
+	})
+	w.WriteHeader(http.StatusOK)
+}
+
+// callbackTimeout aplica a política configurada quando a resposta não chega.
+func (h *Handler) callbackTimeout(id string) {
+	key, ok := h.callbackKey(id)
+	if !ok {
+		return
+	}
+
+	h.mailbox.Do(key.String(), func() {
+		p := h.takeCallback(id)
+		if p == nil {
+			return
+		}
+		defer h.resumeHeld(p)
+
+		log.Printf("[webhook] callback %s de %s nao chegou em %s (%s)", id, key, h.callback.Timeout(), h.callback.OnTimeout)
+
+		if h.callback.OnTimeout == callbackApology {
+			if h.sessions.Mode(key) == session.ModeAI && h.callback.Message != "" {
+				h.send(key, queue.OutboxJob{ConversationID: p.conversationID, Text: h.callback.Message})
+			}
+			return
+		}
+
+		req := p.req
+		req.CallbackURL = ""
+		ctx, cancel := context.WithTimeout(h.stopCtx, 25*time.Second)
+		defer cancel()
+		resp, err := h.chatvolt.Query(ctx, req)
+		if err != nil {
+			h.onQueryError(p.msg, p.conversationID, req, p.notified, err)
+			return
+		}
+		h.deliverAnswer(p.msg, resp)
+	})
+}
+
+// holdForCallback retém a mensagem na consulta pendente da sessão, se houver.
+// Deve rodar dentro da mailbox.
+func (h *Handler) holdForCallback(msg inboundMessage) bool {
+	h.callbackMu.Lock()
+	defer h.callbackMu.Unlock()
+
+	for _, p := range h.callbacks {
+		if p.msg.Key == msg.Key {
+			p.held = append(p.held, msg)
+			return true
+		}
+	}
+	return false
+}
+
+// resumeHeld envia à IA, como uma única mensagem, o que ficou retido enquanto
+// a consulta aguardava o callback. Deve rodar dentro da mailbox.
+func (h *Handler) resumeHeld(p *pendingCallback) {
+	if len(p.held) == 0 {
+		return
+	}
+
+	msg := mergeMessages(p.held)
+	log.Printf("[webhook] %d mensagens retidas de %s seguem para a IA", len(p.held), msg.Key)
+	if err := h.process(h.stopCtx, msg); err != nil {
+		log.Printf("[webhook] erro processando mensagens retidas de %s: %v", msg.Key, err)
+	}
+}
+
+// callbackKey retorna a sessão da consulta pendente com o id.
+func (h *Handler) callbackKey(id string) (session.Key, bool) {
+	h.callbackMu.Lock()
+	defer h.callbackMu.Unlock()
+
+	p, ok := h.callbacks[id]
+	if !ok {
+		return session.Key{}, false
+	}
+	return p.msg.Key, true
+}
+
+// takeCallback remove a consulta pendente e desarma o timeout; nil se ela já
+// foi atendida, expirou ou não existe.
+func (h *Handler) takeCallback(id string) *pendingCallback {
+	h.callbackMu.Lock()
+	defer h.callbackMu.Unlock()
+
+	p, ok := h.callbacks[id]
+	if !ok {
+		return nil
+	}
+	delete(h.callbacks, id)
+	p.timer.Stop()
+	return p
+}
+
+// dropCallbacks descarta as respostas pendentes da sessão (ex.: /reset), junto
+// com as mensagens retidas nelas.
+func (h *Handler) dropCallbacks(key session.Key) {
+	h.callbackMu.Lock()
+	defer h.callbackMu.Unlock()
+
+	for id, p := range h.callbacks {
+		if p.msg.Key == key {
+			delete(h.callbacks, id)
+			p.timer.Stop()
+			if n := len(p.held); n > 0 {
+				log.Printf("[webhook] %d mensagens retidas de %s descartadas", n, key)
+			}
+		}
+	}
+}
+
+// stopCallbacks abandona todas as respostas pendentes no shutdown.
+func (h *Handler) stopCallbacks() {
+	h.callbackMu.Lock()
+	defer h.callbackMu.Unlock()
+
+	pending, held := len(h.callbacks), 0
+	for id, p := range h.callbacks {
+		delete(h.callbacks, id)
+		p.timer.Stop()
+		held += len(p.held)
+	}
+	if pending > 0 {
+		log.Printf("[webhook] %d respostas por callback (%d mensagens retidas) abandonadas no encerramento", pending, held)
+	}
+}
+
+func newCallbackID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
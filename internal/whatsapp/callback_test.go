@@ -0,0 +1,49 @@
+package whatsapp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/session"
+)
+
+func TestCallbackHoldsLaterMessages(t *testing.T) {
+	h := newTestHandler(t, config.InboundProvidersConfig{})
+	h.callback.Enabled = true
+
+	key := session.NewKey("", "5511987654321")
+	p := &pendingCallback{msg: inboundMessage{Key: key, Text: "primeira"}, timer: time.AfterFunc(time.Hour, func() {})}
+	h.callbacks["abc"] = p
+
+	for _, text := range []string{"segunda", "terceira"} {
+		if err := h.process(context.Background(), inboundMessage{Key: key, Text: text}); err != nil {
+			t.Fatalf("process(%q): %v", text, err)
+		}
+	}
+	if len(p.held) != 2 || mergeMessages(p.held).Text != "segunda\nterceira" {
+		t.Fatalf("mensagens retidas = %+v", p.held)
+	}
+
+	// outra sessão não espera pelo callback alheio
+	other := session.NewKey("", "5511912345678")
+	if h.holdForCallback(inboundMessage{Key: other, Text: "oi"}) {
+		t.Error("mensagem de outra sessao retida")
+	}
+
+	// /reset resolve a consulta pendente e descarta o que estava retido
+	h.dropCallbacks(key)
+	if h.holdForCallback(inboundMessage{Key: key, Text: "depois do reset"}) {
+		t.Error("mensagem retida depois do reset")
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeCallback(w, httptest.NewRequest(http.MethodPost, CallbackPath+"?id=abc", strings.NewReader(`{"answer":"tarde demais"}`)))
+	if w.Code != http.StatusGone {
+		t.Errorf("callback descartado = %d, want 410", w.Code)
+	}
+}
@@ -0,0 +1,148 @@
+package whatsapp
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/queue"
+	"whatsapp-ia-integrator/internal/session"
+	"whatsapp-ia-integrator/internal/suppress"
+)
+
+// ações embutidas disponíveis para os comandos
+const (
+	commandReset  = "reset"
+	commandHuman  = "human"
+	commandHelp   = "help"
+	commandOptOut = "optout"
+	commandOptIn  = "optin"
+)
+
+// ordem e descrição usadas na mensagem de ajuda gerada
+var commandHelpOrder = []struct {
+	action, description string
+}{
+	{commandReset, "recomeçar a conversa"},
+	{commandHuman, "falar com um atendente"},
+	{commandHelp, "ver esta lista"},
+	{commandOptOut, "parar de receber mensagens"},
+	{commandOptIn, "voltar a receber mensagens"},
+}
+
+// commandRouter reconhece mensagens que são comandos do cliente.
+type commandRouter struct {
+	// gatilho normalizado -> ação
+	triggers map[string]string
+	replies  map[string]string
+}
+
+func newCommandRouter(commands map[string]config.CommandConfig) *commandRouter {
+	r := &commandRouter{
+		triggers: make(map[string]string),
+		replies:  make(map[string]string),
+	}
+
+	for action, command := range commands {
+		switch action {
+		case commandReset, commandHuman, commandHelp, commandOptOut, commandOptIn:
+		default:
+			log.Printf("[webhook] comando com ação desconhecida ignorado: %s", action)
+			continue
+		}
+
+		for _, trigger := range command.Triggers {
+			if t := foldText(trigger); t != "" {
+				r.triggers[t] = action
+			}
+		}
+		r.replies[action] = command.Reply
+	}
+
+	if r.replies[commandHelp] == "" {
+		r.replies[commandHelp] = r.helpText(commands)
+	}
+	return r
+}
+
+// Match retorna a ação quando a mensagem inteira é um gatilho configurado.
+func (r *commandRouter) Match(text string) (string, bool) {
+	action, ok := r.triggers[foldText(text)]
+	return action, ok
+}
+
+func (r *commandRouter) helpText(commands map[string]config.CommandConfig) string {
+	var b strings.Builder
+	b.WriteString("Comandos disponíveis:")
+	for _, c := range commandHelpOrder {
+		if triggers := commands[c.action].Triggers; len(triggers) > 0 {
+			fmt.Fprintf(&b, "\n• %s: %s", triggers[0], c.description)
+		}
+	}
+	return b.String()
+}
+
+// runCommand executa a ação embutida e envia a confirmação. Deve rodar dentro da mailbox.
+func (h *Handler) runCommand(msg inboundMessage, action string) {
+	key := msg.Key
+	conversationID := h.sessions.Upsert(key, msg.Name).ConversationID
+	text := h.commands.replies[action]
+	log.Printf("[webhook] comando %s recebido de %s", action, key)
+
+	// o comando substitui qualquer consulta à IA que estava sendo repetida
+	// ou aguardando callback
+	h.takeRetry(key)
+	h.dropCallbacks(key)
+
+	switch action {
+	case commandReset:
+		h.sessions.ResetConversation(key)
+		if h.sessions.Mode(key) != session.ModeAI {
+			h.sessions.SetMode(key, session.ModeAI, "command")
+		}
+		conversationID = ""
+	case commandHuman:
+		h.requestHandoff(key, conversationID, "command", text)
+		return
+	case commandOptOut:
+		h.suppressions.Add(key.Phone, suppress.ReasonOptOut, "command")
+	case commandOptIn:
+		// bloqueios da operação não podem ser desfeitos pelo cliente
+		if e, ok := h.suppressions.Get(key.Phone); ok && e.Reason != suppress.ReasonOptOut {
+			return
+		}
+		h.suppressions.Remove(key.Phone)
+	}
+
+	if text != "" {
+		h.send(key, queue.OutboxJob{
+			ConversationID: conversationID,
+			Text:           text,
+			// a confirmação do opt-out ainda precisa chegar ao cliente
+			BypassSuppression: action == commandOptOut,
+		})
+	}
+}
+
+// foldText normaliza a mensagem para comparação: minúsculas, sem acentos,
+// espaços colapsados e sem pontuação final.
+func foldText(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if f, ok := accentFold[r]; ok {
+			r = f
+		}
+		b.WriteRune(r)
+	}
+	return strings.TrimRight(strings.Join(strings.Fields(b.String()), " "), ".!?")
+}
+
+var accentFold = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
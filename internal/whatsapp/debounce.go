@@ -0,0 +1,128 @@
+package whatsapp
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"whatsapp-ia-integrator/internal/routing"
+	"whatsapp-ia-integrator/internal/session"
+)
+
+// inboundMessage é uma mensagem de texto já validada, pronta para a IA.
+type inboundMessage struct {
+	Key  session.Key
+	Name string
+	Text string
+	// Route é o agente escolhido para o contato.
+	Route routing.Decision
+
+	// MessageIDs e TranscriptIDs apontam as mensagens recebidas cobertas por esta
+	// (mais de uma quando o debounce agrupa).
+	MessageIDs    []string
+	TranscriptIDs []int64
+}
+
+// debouncer acumula mensagens do mesmo telefone até a janela ficar ociosa
+// (ou até maxWait desde a primeira mensagem) e entrega o lote de uma vez.
+type debouncer struct {
+	window  time.Duration
+	maxWait time.Duration
+	flush   func(key string, msgs []inboundMessage)
+
+	mu      sync.Mutex
+	pending map[string]*burst
+}
+
+type burst struct {
+	msgs  []inboundMessage
+	first time.Time
+	seq   int
+	timer *time.Timer
+}
+
+func newDebouncer(window, maxWait time.Duration, flush func(key string, msgs []inboundMessage)) *debouncer {
+	if maxWait < window {
+		maxWait = window
+	}
+	return &debouncer{
+		window:  window,
+		maxWait: maxWait,
+		flush:   flush,
+		pending: make(map[string]*burst),
+	}
+}
+
+// Add inclui a mensagem no lote da chave e reinicia a janela, respeitando maxWait.
+func (d *debouncer) Add(key string, msg inboundMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	b, ok := d.pending[key]
+	if !ok {
+		b = &burst{first: now}
+		d.pending[key] = b
+	}
+	b.msgs = append(b.msgs, msg)
+
+	wait := d.window
+	if remaining := b.first.Add(d.maxWait).Sub(now); remaining < wait {
+		wait = remaining
+	}
+
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.seq++
+	seq := b.seq
+	b.timer = time.AfterFunc(wait, func() {
+		d.fire(key, b, seq)
+	})
+}
+
+// FlushAll entrega imediatamente todos os lotes pendentes (usado no shutdown).
+func (d *debouncer) FlushAll() {
+	d.mu.Lock()
+	pending := d.pending
+	d.pending = make(map[string]*burst)
+	d.mu.Unlock()
+
+	for key, b := range pending {
+		b.timer.Stop()
+		d.flush(key, b.msgs)
+	}
+}
+
+func (d *debouncer) fire(key string, b *burst, seq int) {
+	d.mu.Lock()
+	if d.pending[key] != b || b.seq != seq {
+		d.mu.Unlock()
+		return
+	}
+	delete(d.pending, key)
+	d.mu.Unlock()
+
+	d.flush(key, b.msgs)
+}
+
+// mergeMessages concatena o lote em uma única mensagem.
+func mergeMessages(msgs []inboundMessage) inboundMessage {
+	if len(msgs) == 1 {
+		return msgs[0]
+	}
+
+	merged := msgs[len(msgs)-1]
+	texts := make([]string, 0, len(msgs))
+	var messageIDs []string
+	var transcriptIDs []int64
+	for _, m := range msgs {
+		texts = append(texts, m.Text)
+		messageIDs = append(messageIDs, m.MessageIDs...)
+		transcriptIDs = append(transcriptIDs, m.TranscriptIDs...)
+	}
+	merged.Text = strings.Join(texts, "\n")
+	merged.MessageIDs = messageIDs
+	merged.TranscriptIDs = transcriptIDs
+	return merged
+}
@@ -0,0 +1,115 @@
+package whatsapp
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"whatsapp-ia-integrator/internal/chatvolt"
+	"whatsapp-ia-integrator/internal/queue"
+	"whatsapp-ia-integrator/internal/session"
+)
+
+// pendingRetry é uma consulta à IA que falhou e está sendo repetida em segundo plano.
+type pendingRetry struct {
+	cancel context.CancelFunc
+	msg    inboundMessage
+}
+
+// onQueryError avisa o cliente que a IA falhou e, se configurado, transfere
+// para humano ou agenda uma nova tentativa. notified evita repetir o aviso
+// quando a sessão já tinha uma tentativa pendente.
+func (h *Handler) onQueryError(msg inboundMessage, conversationID string, req chatvolt.QueryRequest, notified bool, cause error) {
+	key := msg.Key
+	log.Printf("[webhook] erro consultando chatvolt para %s: %v", key, cause)
+
+	if h.fallback.Handoff {
+		h.requestHandoff(key, conversationID, "chatvolt_error", h.fallback.Message)
+		return
+	}
+
+	if !notified && h.fallback.Message != "" {
+		h.send(key, queue.OutboxJob{
+			ConversationID: conversationID,
+			Text:           h.fallback.Message,
+		})
+	}
+
+	if h.fallback.RetryWindow() > 0 {
+		h.scheduleRetry(msg, req)
+	}
+}
+
+// scheduleRetry repete a consulta até ter sucesso ou vencer o prazo configurado.
+func (h *Handler) scheduleRetry(msg inboundMessage, req chatvolt.QueryRequest) {
+	ctx, cancel := context.WithTimeout(h.stopCtx, h.fallback.RetryWindow())
+	p := &pendingRetry{cancel: cancel, msg: msg}
+
+	h.retryMu.Lock()
+	h.retries[msg.Key] = p
+	h.retryMu.Unlock()
+
+	h.retryWG.Add(1)
+	go func() {
+		defer h.retryWG.Done()
+		defer cancel()
+
+		ticker := time.NewTicker(h.fallback.RetryInterval())
+		defer ticker.Stop()
+
+		for attempt := 1; ; attempt++ {
+			select {
+			case <-ctx.Done():
+				if h.dropRetry(msg.Key, p) {
+					log.Printf("[webhook] nova consulta para %s abandonada apos %d tentativas", msg.Key, attempt-1)
+				}
+				return
+			case <-ticker.C:
+			}
+
+			qctx, qcancel := context.WithTimeout(ctx, 25*time.Second)
+			resp, err := h.chatvolt.Query(qctx, req)
+			qcancel()
+			if err != nil {
+				log.Printf("[webhook] tentativa %d para %s falhou: %v", attempt, msg.Key, err)
+				continue
+			}
+
+			h.mailbox.Do(msg.Key.String(), func() {
+				// uma mensagem nova do cliente assumiu a consulta pendente
+				if !h.dropRetry(msg.Key, p) {
+					return
+				}
+				log.Printf("[webhook] resposta atrasada da IA entregue para %s (tentativa %d)", msg.Key, attempt)
+				h.deliverAnswer(msg, resp)
+			})
+			return
+		}
+	}()
+}
+
+// takeRetry cancela a tentativa pendente da sessão e devolve a mensagem que ela
+// consultava, para ser enviada junto com a nova.
+func (h *Handler) takeRetry(key session.Key) (inboundMessage, bool) {
+	h.retryMu.Lock()
+	defer h.retryMu.Unlock()
+
+	p, ok := h.retries[key]
+	if !ok {
+		return inboundMessage{}, false
+	}
+	delete(h.retries, key)
+	p.cancel()
+	return p.msg, true
+}
+
+func (h *Handler) dropRetry(key session.Key, p *pendingRetry) bool {
+	h.retryMu.Lock()
+	defer h.retryMu.Unlock()
+
+	if h.retries[key] != p {
+		return false
+	}
+	delete(h.retries, key)
+	return true
+}
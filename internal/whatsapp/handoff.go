@@ -0,0 +1,50 @@
+package whatsapp
+
+import (
+	"strings"
+
+	"whatsapp-ia-integrator/internal/session"
+)
+
+// matchesHandoffKeyword indica se o texto do cliente pede atendimento humano.
+// A mensagem inteira precisa ser uma das keywords (sem diferenciar caixa,
+// acentos e pontuação final): "não preciso de atendente" não transfere.
+func matchesHandoffKeyword(text string, keywords []string) bool {
+	folded := foldText(text)
+	if folded == "" {
+		return false
+	}
+	for _, kw := range keywords {
+		if foldText(kw) == folded {
+			return true
+		}
+	}
+	return false
+}
+
+// modeFromMetadata lê a troca de modo sinalizada pelo agente do Chatvolt,
+// via {"handoff": true} ou {"mode": "human"|"paused"|"ai"} na metadata.
+func modeFromMetadata(meta map[string]any) (session.Mode, bool) {
+	if meta == nil {
+		return "", false
+	}
+
+	if raw, ok := meta["mode"].(string); ok {
+		if mode, ok := session.ParseMode(raw); ok {
+			return mode, true
+		}
+	}
+
+	switch v := meta["handoff"].(type) {
+	case bool:
+		if v {
+			return session.ModeHuman, true
+		}
+	case string:
+		if strings.EqualFold(v, "true") {
+			return session.ModeHuman, true
+		}
+	}
+
+	return "", false
+}
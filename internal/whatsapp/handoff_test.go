@@ -0,0 +1,25 @@
+package whatsapp
+
+import "testing"
+
+func TestMatchesHandoffKeyword(t *testing.T) {
+	keywords := []string{"atendente", "falar com um atendente"}
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"atendente", true},
+		{"  Atendente! ", true},
+		{"Falar com um ATENDENTE.", true},
+		{"não preciso de atendente", false},
+		{"o atendente anterior resolveu", false},
+		{"atendentes", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := matchesHandoffKeyword(tt.text, keywords); got != tt.want {
+			t.Errorf("matchesHandoffKeyword(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package whatsapp
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsapp-ia-integrator/internal/calendar"
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/queue"
+	"whatsapp-ia-integrator/internal/session"
+)
+
+const (
+	outOfHoursAI    = "ai"
+	outOfHoursReply = "reply"
+)
+
+// businessHours aplica a política de fora do expediente.
+type businessHours struct {
+	calendar *calendar.Calendar
+	policy   config.OutOfHoursConfig
+	// windows substituem policy nas janelas de out_of_hours_windows (mesma ordem do calendário).
+	windows []config.OutOfHoursConfig
+
+	mu sync.Mutex
+	// aviso já enviado por sessão, para avisar uma vez por janela de cada período fechado
+	notified map[session.Key]notice
+}
+
+type notice struct {
+	next   time.Time
+	window int
+}
+
+func newBusinessHours(cal *calendar.Calendar, cfg config.BusinessHoursConfig) *businessHours {
+	b := &businessHours{
+		calendar: cal,
+		policy:   cfg.OutOfHours,
+		notified: make(map[session.Key]notice),
+	}
+	b.policy.Action = strings.ToLower(b.policy.Action)
+	for _, w := range cfg.OutOfHoursWindows {
+		w.Action = strings.ToLower(w.Action)
+		b.windows = append(b.windows, w.OutOfHoursConfig)
+	}
+	return b
+}
+
+func (b *businessHours) open(now time.Time) bool {
+	return b.calendar.Open(now)
+}
+
+// policyAt retorna a política do período fechado em now e a janela que a definiu (-1 = padrão).
+func (b *businessHours) policyAt(now time.Time) (config.OutOfHoursConfig, int) {
+	if i := b.calendar.ClosedWindow(now); i >= 0 && i < len(b.windows) {
+		return b.windows[i], i
+	}
+	return b.policy, -1
+}
+
+// render troca "{next}" pela próxima abertura.
+func (b *businessHours) render(text string, now time.Time) string {
+	if !strings.Contains(text, "{next}") {
+		return text
+	}
+	return strings.ReplaceAll(text, "{next}", b.calendar.Describe(now))
+}
+
+// firstNotice informa se a sessão ainda não foi avisada nesta janela do período fechado.
+func (b *businessHours) firstNotice(key session.Key, now time.Time, window int) bool {
+	next, _ := b.calendar.NextOpen(now)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if last, ok := b.notified[key]; ok && last.next.Equal(next) && last.window == window {
+		return false
+	}
+	if len(b.notified) > 1024 {
+		for k, n := range b.notified {
+			if n.next.Before(now) {
+				delete(b.notified, k)
+			}
+		}
+	}
+	b.notified[key] = notice{next: next, window: window}
+	return true
+}
+
+// outOfHours avisa o cliente e diz se a IA deve responder, com o agente a usar.
+// Deve rodar dentro da mailbox.
+func (h *Handler) outOfHours(key session.Key, conversationID string, now time.Time) (string, bool) {
+	policy, window := h.hours.policyAt(now)
+	if policy.Message != "" && h.hours.firstNotice(key, now, window) {
+		h.send(key, queue.OutboxJob{
+			ConversationID: conversationID,
+			Text:           h.hours.render(policy.Message, now),
+		})
+	}
+
+	if policy.Action == outOfHoursReply {
+		log.Printf("[webhook] fora do expediente, IA desativada para %s", key)
+		return "", false
+	}
+	return policy.AgentID, true
+}
@@ -0,0 +1,37 @@
+package whatsapp
+
+import (
+	"testing"
+	"time"
+
+	"whatsapp-ia-integrator/internal/calendar"
+	"whatsapp-ia-integrator/internal/config"
+)
+
+func TestBusinessHoursPolicyPerWindow(t *testing.T) {
+	cfg := config.BusinessHoursConfig{
+		Timezone:   "UTC",
+		Schedule:   map[string][]string{"seg": {"08:00-18:00"}},
+		OutOfHours: config.OutOfHoursConfig{Action: "ai", Message: "fechado", AgentID: "noturno"},
+		OutOfHoursWindows: []config.OutOfHoursWindow{
+			{Days: []string{"domingo"}, OutOfHoursConfig: config.OutOfHoursConfig{Action: "REPLY", Message: "domingo", RefuseHandoff: true}},
+		},
+	}
+	cal, err := calendar.New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := newBusinessHours(cal, cfg)
+
+	sunday := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	policy, window := b.policyAt(sunday)
+	if window != 0 || policy.Action != outOfHoursReply || policy.Message != "domingo" || !policy.RefuseHandoff {
+		t.Errorf("domingo = %+v (janela %d)", policy, window)
+	}
+
+	mondayNight := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+	policy, window = b.policyAt(mondayNight)
+	if window != -1 || policy.AgentID != "noturno" || policy.RefuseHandoff {
+		t.Errorf("segunda a noite = %+v (janela %d)", policy, window)
+	}
+}
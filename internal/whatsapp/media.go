@@ -0,0 +1,99 @@
+package whatsapp
+
+import (
+	"fmt"
+	"strings"
+
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/model"
+)
+
+const (
+	mediaForward = "forward"
+	mediaReply   = "reply"
+	mediaHandoff = "handoff"
+	mediaIgnore  = "ignore"
+)
+
+// mediaStrategy retorna a estratégia configurada para o tipo; tipos sem
+// configuração são ignorados, como antes do suporte a mídia.
+func mediaStrategy(strategies map[string]config.MediaStrategy, kind model.MessageKind) config.MediaStrategy {
+	if s, ok := strategies[string(kind)]; ok && s.Action != "" {
+		s.Action = strings.ToLower(s.Action)
+		return s
+	}
+	return config.MediaStrategy{Action: mediaIgnore}
+}
+
+// optionStrategy retorna a regra local da opção escolhida; sem regra, a escolha vai para a IA.
+func optionStrategy(rules map[string]config.MediaStrategy, reply *model.InteractiveReply) config.MediaStrategy {
+	if reply != nil {
+		if s, ok := rules[reply.ID]; ok && s.Action != "" {
+			s.Action = strings.ToLower(s.Action)
+			return s
+		}
+	}
+	return config.MediaStrategy{Action: mediaForward}
+}
+
+// describeMessage monta o texto enviado à IA para mensagens que não são texto puro.
+func describeMessage(msg model.InboundMessage) string {
+	var b strings.Builder
+
+	switch msg.Kind {
+	case model.KindImage:
+		b.WriteString("[O cliente enviou uma imagem")
+	case model.KindAudio:
+		if msg.Voice {
+			b.WriteString("[O cliente enviou uma mensagem de voz")
+		} else {
+			b.WriteString("[O cliente enviou um áudio")
+		}
+	case model.KindVideo:
+		b.WriteString("[O cliente enviou um vídeo")
+	case model.KindSticker:
+		b.WriteString("[O cliente enviou uma figurinha")
+	case model.KindDocument:
+		b.WriteString("[O cliente enviou um documento")
+		if msg.Filename != "" {
+			fmt.Fprintf(&b, " %q", msg.Filename)
+		}
+	case model.KindLocation:
+		fmt.Fprintf(&b, "[O cliente compartilhou a localização %.6f,%.6f", msg.Latitude, msg.Longitude)
+		if msg.LocationName != "" {
+			fmt.Fprintf(&b, " (%s)", msg.LocationName)
+		}
+		if msg.Address != "" {
+			fmt.Fprintf(&b, ", endereço: %s", msg.Address)
+		}
+	case model.KindContact:
+		b.WriteString("[O cliente compartilhou contato")
+		for i, c := range msg.Contacts {
+			if i > 0 {
+				b.WriteString(";")
+			}
+			fmt.Fprintf(&b, " %s %s", c.Name, strings.Join(c.Phones, ", "))
+		}
+	case model.KindInteractive:
+		if msg.Reply == nil {
+			return msg.Text
+		}
+		return fmt.Sprintf("[O cliente escolheu a opção %q (id: %s)]", msg.Reply.Title, msg.Reply.ID)
+	default:
+		return msg.Text
+	}
+
+	if msg.MimeType != "" {
+		fmt.Fprintf(&b, " (%s)", msg.MimeType)
+	}
+	if msg.MediaURL != "" {
+		fmt.Fprintf(&b, ": %s", msg.MediaURL)
+	}
+	b.WriteString("]")
+
+	if msg.Text != "" {
+		b.WriteString("\n")
+		b.WriteString(msg.Text)
+	}
+	return b.String()
+}
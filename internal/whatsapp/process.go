@@ -0,0 +1,329 @@
+package whatsapp
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"whatsapp-ia-integrator/internal/chatvolt"
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/model"
+	"whatsapp-ia-integrator/internal/queue"
+	"whatsapp-ia-integrator/internal/reply"
+	"whatsapp-ia-integrator/internal/session"
+	"whatsapp-ia-integrator/internal/suppress"
+)
+
+// handleInbound processa um webhook retirado da fila de entrada. Roda dentro da
+// mailbox da sessão.
+func (h *Handler) handleInbound(ctx context.Context, payload model.InboundWebhook) (queue.InboundStatus, error) {
+	phone := inboundPhone(payload)
+	messageID := inboundMessageID(payload)
+
+	name := ""
+	if payload.RawContact != nil {
+		name = payload.RawContact["name"]
+	}
+	key := session.NewKey(h.tenantOf(payload), phone)
+
+	normalized := payload.Message()
+	text := normalized.Text
+	strategy := config.MediaStrategy{Action: mediaForward}
+	switch normalized.Kind {
+	case model.KindText:
+	case model.KindInteractive:
+		strategy = optionStrategy(h.options, normalized.Reply)
+		text = describeMessage(normalized)
+	default:
+		strategy = mediaStrategy(h.media, normalized.Kind)
+		text = describeMessage(normalized)
+	}
+
+	if strategy.Action == mediaIgnore || text == "" {
+		log.Printf("[webhook] mensagem %s sem texto ignorada: %#v", normalized.Kind, payload)
+		return queue.InboundStatusIgnored, nil
+	}
+
+	if e, ok := h.suppressions.Get(phone); ok && e.Reason == suppress.ReasonBlocked {
+		log.Printf("[webhook] mensagem de contato bloqueado %s ignorada", key)
+		return queue.InboundStatusIgnored, nil
+	}
+
+	h.sessions.SetDestination(key, payload.Channel, phone)
+
+	route := h.router.Decide(phone, payload.To)
+	agent := route.AgentID
+	if agent == "" {
+		agent = "padrao"
+	}
+	log.Printf("[webhook] roteamento de %s (msg %s): rota=%s agente=%s motivo=%s", key, messageID, route.Route, agent, route.Reason)
+
+	msg := inboundMessage{Key: key, Name: name, Text: text, Route: route, MessageIDs: []string{messageID}}
+	if h.transcripts != nil {
+		conversationID := ""
+		if info, ok := h.sessions.Info(key); ok {
+			conversationID = info.ConversationID
+		}
+		id := h.transcripts.RecordInbound(key.Phone, conversationID, messageID, text, inboundTime(payload))
+		msg.TranscriptIDs = []int64{id}
+	}
+
+	// comandos do cliente (/reset, /humano...) não passam pela IA nem pelo debounce
+	if normalized.Kind == model.KindText {
+		if action, ok := h.commands.Match(text); ok {
+			h.runCommand(msg, action)
+			return queue.InboundStatusDone, nil
+		}
+	}
+
+	// quem pediu opt-out só é atendido pelos comandos (ex.: voltar)
+	if h.suppressions.Suppressed(phone) {
+		log.Printf("[webhook] contato %s em opt-out, IA ignorada", key)
+		return queue.InboundStatusIgnored, nil
+	}
+
+	switch strategy.Action {
+	case mediaReply:
+		h.replyToMedia(msg, strategy.Reply)
+		return queue.InboundStatusDone, nil
+	case mediaHandoff:
+		sess := h.sessions.Upsert(key, name)
+		reason := "media:" + string(normalized.Kind)
+		if normalized.Reply != nil {
+			reason = "option:" + normalized.Reply.ID
+		}
+		h.requestHandoff(key, sess.ConversationID, reason, strategy.Reply)
+		return queue.InboundStatusDone, nil
+	}
+
+	if h.debounce != nil {
+		h.debounce.Add(key.String(), msg)
+		return queue.InboundStatusDebounced, nil
+	}
+
+	if err := h.process(ctx, msg); err != nil {
+		return queue.InboundStatusFailed, err
+	}
+	return queue.InboundStatusDone, nil
+}
+
+// flushBurst processa o lote agrupado pelo debounce como uma única mensagem.
+func (h *Handler) flushBurst(key string, msgs []inboundMessage) {
+	msg := mergeMessages(msgs)
+	if len(msgs) > 1 {
+		log.Printf("[webhook] %d mensagens agrupadas para %s", len(msgs), key)
+	}
+
+	h.mailbox.Do(key, func() {
+		status := queue.InboundStatusDone
+		err := h.process(context.Background(), msg)
+		if err != nil {
+			log.Printf("[webhook] erro processando lote de %s: %v", key, err)
+			status = queue.InboundStatusFailed
+		}
+
+		if h.tracker != nil {
+			for _, id := range msg.MessageIDs {
+				h.tracker.Set(id, msg.Key.Phone, status, err)
+			}
+		}
+	})
+}
+
+// process consulta a IA e enfileira a resposta. Deve rodar dentro da mailbox da sessão.
+func (h *Handler) process(ctx context.Context, msg inboundMessage) error {
+	key := msg.Key
+	phone := key.Phone
+	sess := h.sessions.Upsert(key, msg.Name)
+
+	// uma consulta que falhou antes é absorvida por esta
+	pending, notified := h.takeRetry(key)
+	if notified {
+		msg = mergeMessages([]inboundMessage{pending, msg})
+	}
+
+	mode := h.sessions.Mode(key)
+	if mode == session.ModeAI && matchesHandoffKeyword(msg.Text, h.handoff.Keywords) {
+		h.requestHandoff(key, sess.ConversationID, "keyword", h.handoff.Message)
+		return nil
+	}
+
+	if mode != session.ModeAI {
+		log.Printf("[webhook] sessao %s em modo %s, IA ignorada", key, mode)
+		return nil
+	}
+
+	// com uma resposta por callback pendente, a mensagem espera por ela para
+	// não consultar com o conversationId antigo nem inverter as respostas
+	if h.callback.Enabled && h.holdForCallback(msg) {
+		log.Printf("[webhook] mensagem de %s retida aguardando callback", key)
+		return nil
+	}
+
+	req := chatvolt.QueryRequest{
+		Query:          msg.Text,
+		ConversationID: sess.ConversationID,
+		VisitorID:      sess.VisitorID,
+		Contact: &chatvolt.Contact{
+			FirstName: msg.Name,
+			Phone:     phone,
+		},
+		AgentID:     msg.Route.AgentID,
+		ModelName:   msg.Route.ModelName,
+		Temperature: msg.Route.Temperature,
+	}
+
+	if now := time.Now(); !h.hours.open(now) {
+		agentID, ok := h.outOfHours(key, sess.ConversationID, now)
+		if !ok {
+			return nil
+		}
+		if agentID != "" {
+			req.AgentID = agentID
+		}
+	}
+
+	if h.callback.Enabled {
+		return h.queryAsync(ctx, msg, sess.ConversationID, req, notified)
+	}
+	if h.streaming.Enabled {
+		return h.streamAnswer(ctx, msg, sess.ConversationID, req, notified)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 25*time.Second)
+	defer cancel()
+
+	resp, err := h.chatvolt.Query(ctx, req)
+	if err != nil {
+		h.onQueryError(msg, sess.ConversationID, req, notified, err)
+		return err
+	}
+
+	h.deliverAnswer(msg, resp)
+	return nil
+}
+
+// deliverAnswer grava o estado da conversa devolvido pela IA e enfileira a
+// resposta, se a sessão ainda estiver com a IA. Deve rodar dentro da mailbox.
+func (h *Handler) deliverAnswer(msg inboundMessage, resp *chatvolt.QueryResponse) {
+	key := msg.Key
+
+	h.sessions.UpdateConversation(key, resp.ConversationID, resp.VisitorID)
+	if h.transcripts != nil {
+		h.transcripts.SetConversation(resp.ConversationID, msg.TranscriptIDs...)
+	}
+
+	// a sessão pode ter sido transferida enquanto a IA respondia
+	if mode := h.sessions.Mode(key); mode != session.ModeAI {
+		log.Printf("[webhook] sessao %s passou para modo %s, resposta da IA descartada", key, mode)
+		return
+	}
+
+	if mode, ok := modeFromMetadata(resp.Metadata); ok && mode != session.ModeAI {
+		h.sessions.SetMode(key, mode, "chatvolt")
+		log.Printf("[webhook] chatvolt solicitou modo %s para %s", mode, key)
+	}
+
+	h.enqueueAnswer(key, resp.ConversationID, resp.Answer, resp.Metadata)
+}
+
+// enqueueAnswer converte a resposta da IA em mensagens (texto formatado para o
+// canal da sessão e quebrado em partes, seguido dos anexos) e as enfileira em ordem.
+func (h *Handler) enqueueAnswer(key session.Key, conversationID, answer string, metadata map[string]any) {
+	name, _ := h.sessions.Destination(key)
+	channel := h.channels.Get(name)
+	split := h.split
+	if channel.MaxLength > 0 {
+		split.MaxLength = channel.MaxLength
+	}
+
+	for _, job := range reply.Build(answer, metadata) {
+		job.ConversationID = conversationID
+
+		// sem suporte a botões/listas, as opções seguem numeradas no texto
+		if job.Type == "interactive" && !channel.Interactive {
+			job.Type = "text"
+			job.Text = reply.InteractiveAsText(job.Interactive)
+			job.Interactive = nil
+		}
+
+		if job.Type != "text" {
+			if job.Interactive != nil {
+				job.Interactive.Body = reply.FormatFor(channel.Format, job.Interactive.Body)
+			}
+			h.send(key, job)
+			continue
+		}
+
+		parts := reply.Split(reply.FormatFor(channel.Format, job.Text), split)
+		for i, part := range parts {
+			job.Text = part
+			job.Part = i + 1
+			job.Parts = len(parts)
+			h.send(key, job)
+		}
+	}
+}
+
+// send enfileira o job para o contato da sessão, no canal e no formato de
+// telefone recebidos da CSA.
+func (h *Handler) send(key session.Key, job queue.OutboxJob) {
+	job.Phone = key.Phone
+	job.Channel, job.Destination = h.sessions.Destination(key)
+	h.outbox.Enqueue(job)
+}
+
+// requestHandoff transfere a sessão para atendimento humano e avisa o cliente.
+// Fora do expediente a transferência pode ser recusada pela política configurada.
+func (h *Handler) requestHandoff(key session.Key, conversationID, reason, message string) {
+	if h.sessions.Mode(key) != session.ModeAI {
+		return
+	}
+
+	if now := time.Now(); !h.hours.open(now) {
+		if policy, _ := h.hours.policyAt(now); policy.RefuseHandoff {
+			log.Printf("[webhook] transferencia de %s recusada fora do expediente (%s)", key, reason)
+			h.send(key, queue.OutboxJob{
+				ConversationID: conversationID,
+				Text:           h.hours.render(policy.HandoffMessage, now),
+			})
+			return
+		}
+	}
+
+	h.sessions.SetMode(key, session.ModeHuman, reason)
+	log.Printf("[webhook] %s transferido para atendimento humano (%s)", key, reason)
+
+	if message != "" {
+		h.send(key, queue.OutboxJob{
+			ConversationID: conversationID,
+			Text:           message,
+		})
+	}
+}
+
+// replyToMedia responde com a mensagem fixa configurada para o tipo, sem consultar a IA.
+func (h *Handler) replyToMedia(msg inboundMessage, text string) {
+	sess := h.sessions.Upsert(msg.Key, msg.Name)
+	if mode := h.sessions.Mode(msg.Key); mode != session.ModeAI || text == "" {
+		return
+	}
+
+	h.send(msg.Key, queue.OutboxJob{
+		ConversationID: sess.ConversationID,
+		Text:           text,
+	})
+}
+
+// inboundPhone extrai o telefone do remetente com os fallbacks do payload.
+func inboundPhone(payload model.InboundWebhook) string {
+	phone := strings.TrimSpace(payload.From)
+	if phone == "" {
+		phone = strings.TrimSpace(payload.PhoneFromRaw())
+	}
+	if phone == "" && payload.RawContact != nil {
+		phone = strings.TrimSpace(payload.RawContact["phone"])
+	}
+	return phone
+}
@@ -0,0 +1,155 @@
+package whatsapp
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"whatsapp-ia-integrator/internal/chatvolt"
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/session"
+)
+
+// streamTimeout limita a geração inteira; cada parte sai assim que fica pronta.
+const streamTimeout = 90 * time.Second
+
+// fim de frase (ignora "1." de listas numeradas)
+var streamSentenceEnd = regexp.MustCompile(`[^\d\s][.!?…]["')\]]*\s`)
+
+// streamChunker acumula os tokens e libera trechos terminados em parágrafo ou
+// frase completos.
+type streamChunker struct {
+	minChars int
+	interval time.Duration
+	buf      string
+	last     time.Time
+}
+
+func newStreamChunker(cfg config.StreamingConfig, now time.Time) *streamChunker {
+	return &streamChunker{minChars: cfg.MinChars, interval: cfg.FlushInterval(), last: now}
+}
+
+func (c *streamChunker) Add(token string) {
+	c.buf += token
+}
+
+// Next devolve o próximo trecho quando o acumulado passa de minChars ou o
+// intervalo desde o último envio venceu, cortando no último parágrafo (ou, sem
+// ele, na última frase) completo.
+func (c *streamChunker) Next(now time.Time) (string, bool) {
+	if utf8.RuneCountInString(c.buf) < c.minChars && now.Sub(c.last) < c.interval {
+		return "", false
+	}
+
+	cut := streamBoundary(c.buf)
+	if cut <= 0 {
+		return "", false
+	}
+	chunk := strings.TrimSpace(c.buf[:cut])
+	c.buf = c.buf[cut:]
+	c.last = now
+	return chunk, chunk != ""
+}
+
+// Rest devolve o que sobrou ao fim do stream.
+func (c *streamChunker) Rest() string {
+	rest := c.buf
+	c.buf = ""
+	return rest
+}
+
+// streamBoundary retorna a posição do último corte seguro, fora de blocos de código.
+func streamBoundary(text string) int {
+	outsideCode := func(i int) bool {
+		return strings.Count(text[:i], "```")%2 == 0
+	}
+
+	for i := strings.LastIndex(text, "\n\n"); i >= 0; i = strings.LastIndex(text[:i], "\n\n") {
+		if outsideCode(i) {
+			return i + 2
+		}
+	}
+
+	ends := streamSentenceEnd.FindAllStringIndex(text, -1)
+	for i := len(ends) - 1; i >= 0; i-- {
+		if outsideCode(ends[i][1]) {
+			return ends[i][1]
+		}
+	}
+	return 0
+}
+
+// streamAnswer consulta a IA em streaming e envia a resposta em partes à medida
+// que frases ou parágrafos ficam completos. Deve rodar dentro da mailbox.
+func (h *Handler) streamAnswer(ctx context.Context, msg inboundMessage, conversationID string, req chatvolt.QueryRequest, notified bool) error {
+	key := msg.Key
+	ctx, cancel := context.WithTimeout(ctx, streamTimeout)
+	defer cancel()
+
+	events, err := h.chatvolt.Stream(ctx, req)
+	if err != nil {
+		h.onQueryError(msg, conversationID, req, notified, err)
+		return err
+	}
+
+	chunker := newStreamChunker(h.streaming, time.Now())
+	ticker := time.NewTicker(h.streaming.FlushInterval())
+	defer ticker.Stop()
+
+	sent := 0
+	flush := func(now time.Time) {
+		chunk, ok := chunker.Next(now)
+		// a sessão pode ter sido transferida durante a geração
+		if !ok || h.sessions.Mode(key) != session.ModeAI {
+			return
+		}
+		h.enqueueAnswer(key, conversationID, chunk, nil)
+		sent++
+	}
+
+	var final *chatvolt.QueryResponse
+loop:
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				break loop
+			}
+			switch {
+			case ev.Err != nil:
+				err = ev.Err
+				break loop
+			case ev.Response != nil:
+				final = ev.Response
+			default:
+				chunker.Add(ev.Token)
+				flush(time.Now())
+			}
+		case now := <-ticker.C:
+			flush(now)
+		}
+	}
+
+	if err == nil && final == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	if err != nil && sent == 0 {
+		h.onQueryError(msg, conversationID, req, notified, err)
+		return err
+	}
+	if err != nil {
+		log.Printf("[webhook] streaming de %s interrompido apos %d partes: %v", key, sent, err)
+	}
+
+	// o evento final traz conversationId e metadata; o texto já enviado sai dele
+	rest := chatvolt.QueryResponse{ConversationID: conversationID, VisitorID: req.VisitorID}
+	if final != nil {
+		rest = *final
+	}
+	rest.Answer = chunker.Rest()
+	h.deliverAnswer(msg, &rest)
+	return err
+}
@@ -3,106 +3,279 @@ package whatsapp
 import (
 	"context"
 	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"whatsapp-ia-integrator/internal/adapter"
+	"whatsapp-ia-integrator/internal/calendar"
 	"whatsapp-ia-integrator/internal/chatvolt"
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/dedup"
 	"whatsapp-ia-integrator/internal/model"
 	"whatsapp-ia-integrator/internal/queue"
+	"whatsapp-ia-integrator/internal/reply"
+	"whatsapp-ia-integrator/internal/routing"
 	"whatsapp-ia-integrator/internal/session"
+	"whatsapp-ia-integrator/internal/suppress"
+	"whatsapp-ia-integrator/internal/transcript"
 )
 
+// duplicatesSuppressed conta reentregas de webhooks ignoradas (exposto em /debug/vars).
+var duplicatesSuppressed = expvar.NewInt("inbound_duplicates_suppressed")
+
 // Handler recebe webhooks da CSA e orquestra o fluxo IA -> CSA.
 type Handler struct {
-	chatvolt *chatvolt.Client
-	sessions *session.Manager
-	outbox   *queue.Outbox
-	jobs     *queue.JobManager
+	chatvolt    *chatvolt.Client
+	sessions    *session.Manager
+	outbox      *queue.Outbox
+	jobs        *queue.JobManager
+	transcripts *transcript.Store
+	tracker     *queue.InboundTracker
+	dedup       *dedup.Store
+	// suppressions lista quem pediu opt-out ou foi bloqueado.
+	suppressions *suppress.List
+
+	// inbound desacopla o recebimento do processamento: o webhook responde na hora.
+	inbound *queue.InboundQueue
+	// mailbox serializa o processamento por sessão para que cada mensagem
+	// enxergue o conversationId gravado pela anterior.
+	mailbox *queue.Mailbox
+	// debounce agrupa rajadas do mesmo telefone; nil quando desabilitado.
+	debounce *debouncer
+	handoff  config.HandoffConfig
+	media    map[string]config.MediaStrategy
+	options  map[string]config.MediaStrategy
+	commands *commandRouter
+	hours    *businessHours
+	router   *routing.Router
+	adapters *adapter.Registry
+	split    reply.SplitOptions
+	channels config.Channels
+	// streaming envia a resposta da IA em partes enquanto ela é gerada.
+	streaming  config.StreamingConfig
+	tenantFrom string
+
+	// fallback e as novas tentativas em segundo plano quando a IA falha.
+	fallback   config.FallbackConfig
+	retryMu    sync.Mutex
+	retries    map[session.Key]*pendingRetry
+	retryWG    sync.WaitGroup
+	stopCtx    context.Context
+	stopCancel context.CancelFunc
+
+	// callback (modo assíncrono): consultas aguardando resposta, por id.
+	callback   config.CallbackConfig
+	callbackMu sync.Mutex
+	callbacks  map[string]*pendingCallback
+}
+
+func NewHandler(cv *chatvolt.Client, sm *session.Manager, out *queue.Outbox, jm *queue.JobManager, ts *transcript.Store, tracker *queue.InboundTracker, dd *dedup.Store, sl *suppress.List, cal *calendar.Calendar, router *routing.Router, cfg config.WhatsAppConfig, hoursCfg config.BusinessHoursConfig, channels config.Channels, ia config.ChatvoltConfig) *Handler {
+	h := &Handler{
+		chatvolt:     cv,
+		sessions:     sm,
+		outbox:       out,
+		jobs:         jm,
+		transcripts:  ts,
+		tracker:      tracker,
+		dedup:        dd,
+		suppressions: sl,
+		mailbox:      queue.NewMailbox(),
+		handoff:      cfg.Handoff,
+		media:        cfg.Media,
+		options:      cfg.Options,
+		commands:     newCommandRouter(cfg.Commands),
+		hours:        newBusinessHours(cal, hoursCfg),
+		router:       router,
+		adapters:     adapter.NewRegistry(cfg.Providers.Default, cfg.Providers.MetaVerifyToken, cfg.Providers.MetaAppSecret),
+		split:        reply.SplitOptions{MaxLength: cfg.Split.MaxLength, Numbering: cfg.Split.Numbering},
+		channels:     channels,
+		streaming:    ia.Streaming,
+		callback:     ia.Callback,
+		callbacks:    make(map[string]*pendingCallback),
+		tenantFrom:   cfg.TenantFrom,
+		fallback:     cfg.Fallback,
+		retries:      make(map[session.Key]*pendingRetry),
+	}
+	h.stopCtx, h.stopCancel = context.WithCancel(context.Background())
+
+	h.inbound = queue.NewInboundQueue(cfg.InboundWorkers, cfg.InboundQueueSize, tracker, h.mailbox, h.handleInbound)
+
+	if cfg.Debounce.Window() > 0 {
+		h.debounce = newDebouncer(cfg.Debounce.Window(), cfg.Debounce.MaxWait(), h.flushBurst)
+	}
+
+	return h
 }
 
-func NewHandler(cv *chatvolt.Client, sm *session.Manager, out *queue.Outbox, jm *queue.JobManager) *Handler {
-	return &Handler{chatvolt: cv, sessions: sm, outbox: out, jobs: jm}
+// Start dispara os workers da fila de entrada.
+func (h *Handler) Start() {
+	h.inbound.Start()
+}
+
+// Stop processa o que restou na fila de entrada, entrega as mensagens
+// ainda retidas na janela de debounce e abandona as novas tentativas e os
+// callbacks pendentes.
+func (h *Handler) Stop() {
+	h.inbound.Stop()
+	if h.debounce != nil {
+		h.debounce.FlushAll()
+	}
+	h.stopCallbacks()
+	h.stopCancel()
+	h.retryWG.Wait()
 }
 
+// ServeHTTP recebe o webhook. O formato vem do sufixo do endpoint
+// (/whatsapp/webhook/{csa,gupshup,meta}) ou é detectado pelo corpo; um corpo
+// pode trazer vários eventos (Cloud API).
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var provider adapter.InboundAdapter
+	if name := providerFromPath(r.URL.Path); name != "" {
+		if provider = h.adapters.Named(name); provider == nil {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	if r.Method == http.MethodGet {
+		h.serveChallenge(w, r, provider)
+		return
+	}
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
 
-	var payload model.InboundWebhook
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		log.Printf("[webhook] erro lendo payload: %v", err)
-		http.Error(w, "invalid payload", http.StatusBadRequest)
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, "erro lendo corpo", http.StatusBadRequest)
+		return
+	}
+	if provider == nil {
+		provider = h.adapters.Detect(body)
+	}
+	if signed, ok := provider.(adapter.SignatureVerifier); ok && !signed.VerifySignature(r.Header, body) {
+		authRejected.Add("invalid_provider_signature", 1)
+		log.Printf("[webhook-auth] assinatura %s invalida de %s", provider.Name(), r.RemoteAddr)
+		http.Error(w, "assinatura invalida", http.StatusUnauthorized)
 		return
 	}
 
-	if strings.EqualFold(payload.Event, "status") {
-		h.handleStatusWebhook(payload)
-		w.WriteHeader(http.StatusOK)
+	events, err := provider.Parse(body)
+	if err != nil {
+		log.Printf("[webhook] erro lendo payload %s: %v", provider.Name(), err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
 		return
 	}
 
-	phone := strings.TrimSpace(payload.From)
-	if phone == "" {
-		phone = strings.TrimSpace(payload.PhoneFromRaw())
+	var acks []webhookAck
+	for _, payload := range events {
+		ack, status, msg := h.accept(payload)
+		if status != http.StatusOK {
+			http.Error(w, msg, status)
+			return
+		}
+		if ack != nil {
+			acks = append(acks, *ack)
+		}
 	}
-	if phone == "" && payload.RawContact != nil {
-		phone = strings.TrimSpace(payload.RawContact["phone"])
+	writeAcks(w, acks)
+}
+
+// serveChallenge responde ao GET de verificação do provedor (hub.challenge da Meta).
+func (h *Handler) serveChallenge(w http.ResponseWriter, r *http.Request, provider adapter.InboundAdapter) {
+	if provider == nil {
+		provider = h.adapters.Named("meta")
 	}
-	if phone == "" {
-		log.Printf("[webhook] payload sem telefone: %#v", payload)
-		http.Error(w, "missing phone", http.StatusBadRequest)
+
+	verifier, ok := provider.(adapter.ChallengeVerifier)
+	if !ok {
+		w.WriteHeader(http.StatusMethodNotAllowed)
 		return
 	}
+	challenge, ok := verifier.VerifyChallenge(r.URL.Query())
+	if !ok {
+		log.Printf("[webhook] verificacao %s recusada", provider.Name())
+		http.Error(w, "verificacao recusada", http.StatusForbidden)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.WriteString(w, challenge)
+}
 
-	text := strings.TrimSpace(payload.MessageText)
-	if text == "" {
-		text = strings.TrimSpace(payload.TextFromRaw())
+// accept registra um evento normalizado. Retorna o ack da mensagem (nil para
+// status) ou o código/mensagem de erro HTTP.
+func (h *Handler) accept(payload model.InboundWebhook) (*webhookAck, int, string) {
+	if strings.EqualFold(payload.Event, "status") {
+		h.handleStatusWebhook(payload)
+		return nil, http.StatusOK, ""
 	}
-	if text == "" {
-		log.Printf("[webhook] mensagem sem texto ignorada: %#v", payload)
-		w.WriteHeader(http.StatusNoContent)
-		return
+
+	phone := inboundPhone(payload)
+	if phone == "" {
+		log.Printf("[webhook] payload sem telefone: %#v", payload)
+		return nil, http.StatusBadRequest, "missing phone"
 	}
 
-	name := ""
-	if payload.RawContact != nil {
-		name = payload.RawContact["name"]
+	dedupIDs := []string{strings.TrimSpace(payload.MessageID), strings.TrimSpace(payload.PlatformMessageID)}
+	if h.dedup != nil && h.dedup.Seen(dedupIDs...) {
+		duplicatesSuppressed.Add(1)
+		log.Printf("[webhook] reentrega ignorada messageId=%s platformMessageId=%s de %s", payload.MessageID, payload.PlatformMessageID, phone)
+		return &webhookAck{MessageID: inboundMessageID(payload), Status: "duplicate"}, http.StatusOK, ""
 	}
-	sess := h.sessions.Upsert(phone, name)
 
-	req := chatvolt.QueryRequest{
-		Query:          text,
-		ConversationID: sess.ConversationID,
-		VisitorID:      sess.VisitorID,
-		Contact: &chatvolt.Contact{
-			FirstName: name,
-			Phone:     phone,
-		},
+	messageID := inboundMessageID(payload)
+	if messageID == "" {
+		messageID = fmt.Sprintf("local-%d", time.Now().UnixNano())
+		payload.MessageID = messageID
 	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), 25*time.Second)
-	defer cancel()
+	key := session.NewKey(h.tenantOf(payload), phone)
+	if !h.inbound.Enqueue(queue.InboundJob{Key: key.String(), MessageID: messageID, Phone: key.Phone, Payload: payload}) {
+		if h.dedup != nil {
+			// a reentrega da CSA precisa ser aceita
+			h.dedup.Forget(dedupIDs...)
+		}
+		return nil, http.StatusServiceUnavailable, "fila de entrada cheia"
+	}
 
-	resp, err := h.chatvolt.Query(ctx, req)
-	if err != nil {
-		log.Printf("[webhook] erro chamando chatvolt: %v", err)
-		http.Error(w, "failed to query IA", http.StatusBadGateway)
+	return &webhookAck{MessageID: messageID, Status: string(queue.InboundStatusQueued)}, http.StatusOK, ""
+}
+
+type webhookAck struct {
+	MessageID string `json:"messageId"`
+	Status    string `json:"status"`
+}
+
+// writeAcks responde 200: sem corpo para eventos de status, um objeto para uma
+// mensagem e uma lista quando o corpo trouxe várias.
+func writeAcks(w http.ResponseWriter, acks []webhookAck) {
+	if len(acks) == 0 {
+		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	h.sessions.UpdateConversation(phone, resp.ConversationID, resp.VisitorID)
-
-	h.outbox.Enqueue(queue.OutboxJob{
-		Phone:          phone,
-		ConversationID: resp.ConversationID,
-		Text:           resp.Answer,
-	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if len(acks) == 1 {
+		_ = json.NewEncoder(w).Encode(acks[0])
+		return
+	}
+	_ = json.NewEncoder(w).Encode(acks)
+}
 
-	w.WriteHeader(http.StatusAccepted)
+// providerFromPath extrai o provedor de /whatsapp/webhook/{provedor}.
+func providerFromPath(path string) string {
+	_, name, _ := strings.Cut(strings.Trim(path, "/"), "webhook/")
+	return strings.Trim(name, "/")
 }
 
 func (h *Handler) handleStatusWebhook(payload model.InboundWebhook) {
@@ -110,10 +283,7 @@ func (h *Handler) handleStatusWebhook(payload model.InboundWebhook) {
 		return
 	}
 
-	messageID := strings.TrimSpace(payload.MessageID)
-	if messageID == "" {
-		messageID = strings.TrimSpace(payload.PlatformMessageID)
-	}
+	messageID := inboundMessageID(payload)
 	status := queue.JobStatus(payload.Status)
 
 	phone := strings.TrimSpace(payload.To)
@@ -122,4 +292,42 @@ func (h *Handler) handleStatusWebhook(payload model.InboundWebhook) {
 	}
 
 	h.jobs.UpsertStatus(messageID, status, phone, payload.ConversationID)
+	if h.transcripts != nil {
+		h.transcripts.UpdateStatus(messageID, payload.Status)
+	}
+}
+
+// tenantOf identifica o número/tenant de destino da mensagem recebida.
+func (h *Handler) tenantOf(payload model.InboundWebhook) string {
+	switch h.tenantFrom {
+	case "none":
+		return ""
+	case "platform":
+		return strings.TrimSpace(payload.PlatformID)
+	}
+
+	if to := strings.TrimSpace(payload.To); to != "" {
+		return to
+	}
+	return strings.TrimSpace(payload.PlatformID)
+}
+
+// inboundMessageID retorna o id CSA da mensagem, com fallback para o id da plataforma.
+func inboundMessageID(payload model.InboundWebhook) string {
+	if id := strings.TrimSpace(payload.MessageID); id != "" {
+		return id
+	}
+	return strings.TrimSpace(payload.PlatformMessageID)
+}
+
+// inboundTime converte o timestamp do webhook (segundos ou milissegundos).
+func inboundTime(payload model.InboundWebhook) time.Time {
+	switch {
+	case payload.Timestamp <= 0:
+		return time.Now().UTC()
+	case payload.Timestamp > 1e12:
+		return time.UnixMilli(payload.Timestamp).UTC()
+	default:
+		return time.Unix(payload.Timestamp, 0).UTC()
+	}
 }
@@ -0,0 +1,107 @@
+package whatsapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"whatsapp-ia-integrator/internal/chatvolt"
+	"whatsapp-ia-integrator/internal/config"
+	"whatsapp-ia-integrator/internal/queue"
+	"whatsapp-ia-integrator/internal/session"
+)
+
+const metaMessage = `{"object":"whatsapp_business_account","entry":[{"id":"1","changes":[{"field":"messages","value":{
+"metadata":{"display_phone_number":"15550783881","phone_number_id":"106540352242922"},
+"contacts":[{"profile":{"name":"Ana"},"wa_id":"5511987654321"}],
+"messages":[{"from":"5511987654321","id":"wamid.teste1","timestamp":"1760850000","type":"text","text":{"body":"oi"}}]}}]}]}`
+
+func newTestHandler(t *testing.T, providers config.InboundProvidersConfig) *Handler {
+	t.Helper()
+	sessions := session.NewManager(time.Minute, 0)
+	t.Cleanup(sessions.Stop)
+
+	cfg := config.WhatsAppConfig{InboundWorkers: 1, InboundQueueSize: 10, Providers: providers}
+	return NewHandler(chatvolt.NewClient(config.ChatvoltConfig{}), sessions, nil, queue.NewJobManager(), nil,
+		queue.NewInboundTracker(time.Hour, 100), nil, nil, nil, nil, cfg, config.BusinessHoursConfig{}, nil, config.ChatvoltConfig{})
+}
+
+func TestServeHTTPAcceptsProviders(t *testing.T) {
+	h := newTestHandler(t, config.InboundProvidersConfig{})
+
+	tests := []struct {
+		path, body string
+		want       string
+	}{
+		{"/whatsapp/webhook", metaMessage, `"messageId":"wamid.teste1"`},
+		{"/whatsapp/webhook/meta", strings.Replace(metaMessage, "teste1", "teste2", 1), `"messageId":"wamid.teste2"`},
+		{"/whatsapp/webhook/csa", `{"event":"message","messageId":"csa-1","from":"5511987654321","type":"text","messageText":"oi"}`, `"messageId":"csa-1"`},
+		{"/whatsapp/webhook/gupshup", `{"app":"LojaBot","type":"user-event","payload":{"phone":"5511987654321","type":"opted-in"}}`, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("POST %s = %d %q, want 200 com %s", tt.path, w.Code, w.Body.String(), tt.want)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/whatsapp/webhook/telegram", strings.NewReader("{}")))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("provedor desconhecido = %d, want 404", w.Code)
+	}
+}
+
+func TestServeHTTPMetaChallengeAndSignature(t *testing.T) {
+	h := newTestHandler(t, config.InboundProvidersConfig{MetaVerifyToken: "verifica", MetaAppSecret: "app-secret"})
+	verifier, err := NewVerifier(config.WebhookAuthConfig{
+		HMACSecret: "outro", SignatureHeader: "X-Signature", TimestampHeader: "X-Timestamp", MaxSkewSeconds: 300,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped := verifier.Wrap(h)
+
+	w := httptest.NewRecorder()
+	wrapped.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/whatsapp/webhook/meta?hub.mode=subscribe&hub.verify_token=verifica&hub.challenge=42", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "42" {
+		t.Errorf("challenge = %d %q, want 200 42", w.Code, w.Body.String())
+	}
+
+	mac := hmac.New(sha256.New, []byte("app-secret"))
+	mac.Write([]byte(metaMessage))
+	signature := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	for _, tt := range []struct {
+		signature string
+		want      int
+	}{
+		{"", http.StatusUnauthorized},
+		{"sha256=00", http.StatusUnauthorized},
+		{signature, http.StatusOK},
+	} {
+		r := httptest.NewRequest(http.MethodPost, "/whatsapp/webhook/meta", strings.NewReader(metaMessage))
+		if tt.signature != "" {
+			r.Header.Set("X-Hub-Signature-256", tt.signature)
+		}
+		w := httptest.NewRecorder()
+		wrapped.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("POST meta assinatura %q = %d, want %d", tt.signature, w.Code, tt.want)
+		}
+	}
+
+	// fora da rota da Meta a assinatura genérica continua obrigatória
+	w = httptest.NewRecorder()
+	wrapped.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/whatsapp/webhook/csa", strings.NewReader(`{"from":"5511987654321"}`)))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("POST csa sem assinatura = %d, want 401", w.Code)
+	}
+}